@@ -0,0 +1,297 @@
+package format
+
+import (
+	"github.com/faetools/format/golang"
+	"github.com/faetools/format/yaml"
+	"github.com/pkg/errors"
+)
+
+// Config is the formatting configuration of a project, usually read from a yaml file.
+type Config struct {
+	Go   GoConfig   `yaml:"go"`
+	YAML YAMLConfig `yaml:"yaml,omitempty"`
+}
+
+// GoConfig defines how go code is formatted.
+type GoConfig struct {
+	// LocalPrefixes are the import path prefixes that get their own import groups, see golang.WithLocalPrefixes.
+	LocalPrefixes []string `yaml:"localPrefixes,omitempty"`
+	// SideEffectImportsGroup puts blank and dot imports into a separate group.
+	SideEffectImportsGroup bool `yaml:"sideEffectImportsGroup,omitempty"`
+	// MaxLineLength is the length after which lines are wrapped, if set.
+	MaxLineLength int `yaml:"maxLineLength,omitempty"`
+	// DocCommentWidth is the width at which doc comments are wrapped, if set.
+	DocCommentWidth int `yaml:"docCommentWidth,omitempty"`
+	// Rewrite contains rewrite rules of the form "pattern -> replacement", see golang.ParseRule.
+	Rewrite []string `yaml:"rewrite,omitempty"`
+	// Tags defines how struct tags are formatted.
+	Tags TagConfig `yaml:"tags,omitempty"`
+	// EmbeddedLiterals formats raw string literals marked with comments like //format:json.
+	EmbeddedLiterals bool `yaml:"embeddedLiterals,omitempty"`
+	// LiteralCallSites are the functions whose raw string literal arguments are formatted, by kind of literal,
+	// see golang.WithLiteralCallSites.
+	LiteralCallSites map[string][]string `yaml:"literalCallSites,omitempty"`
+	// Pipelines define which steps are run for certain files, see golang.WithPipeline.
+	Pipelines []PipelineConfig `yaml:"pipelines,omitempty"`
+}
+
+// YAMLConfig defines how yaml files are formatted.
+type YAMLConfig struct {
+	// Indent is the number of spaces that nested mappings are indented with, 2 by default.
+	Indent int `yaml:"indent,omitempty"`
+	// Sequences is the style of sequences in mappings: indented (default) or flush with their key.
+	Sequences string `yaml:"sequences,omitempty"`
+	// Quoting is the policy which strings are quoted: yaml1.2 (default) only quotes strings that aren't strings
+	// in YAML 1.2, yaml1.1 also quotes strings like yes or on.
+	Quoting string `yaml:"quoting,omitempty"`
+	// Quotes are the preferred quotes: single (default) or double.
+	Quotes string `yaml:"quotes,omitempty"`
+	// QuoteKeys also quotes keys that aren't strings in YAML 1.1 if quoting is yaml1.1, see yaml.QuoteKeys.
+	QuoteKeys bool `yaml:"quoteKeys,omitempty"`
+	// Anchors defines how anchors are changed: keep (default), expand or hoist, see yaml.ExpandAliases and yaml.HoistAnchors.
+	Anchors string `yaml:"anchors,omitempty"`
+	// KeyOrders define how the keys of mappings are ordered in certain files.
+	KeyOrders []KeyOrderConfig `yaml:"keyOrders,omitempty"`
+}
+
+// KeyOrderConfig defines how the keys of mappings are ordered in yaml files matching any of the paths.
+type KeyOrderConfig struct {
+	// Paths are patterns of the files, see WithGoPipeline.
+	Paths []string `yaml:"paths"`
+	// Profile is the name of a well-known key order, see yaml.KeyOrderProfiles.
+	Profile string `yaml:"profile,omitempty"`
+	// Keys lists the keys of mappings by their path, see yaml.KeyOrder.
+	Keys yaml.KeyOrder `yaml:"keys,omitempty"`
+	// Sort sorts all other keys alphabetically.
+	Sort bool `yaml:"sort,omitempty"`
+}
+
+// PipelineConfig defines which steps are run for go files matching any of the paths.
+type PipelineConfig struct {
+	// Paths are patterns of the files, see WithGoPipeline.
+	Paths []string `yaml:"paths"`
+	// Steps are the steps to run, e.g. imports, gofmt or gofumpt.
+	Steps []golang.Step `yaml:"steps"`
+}
+
+// TagConfig defines how struct tags are formatted.
+type TagConfig struct {
+	// Order is the order of the keys of struct tags, see golang.WithTagOrder.
+	Order []string `yaml:"order,omitempty"`
+	// Align aligns the keys of struct tags of consecutive fields.
+	Align bool `yaml:"align,omitempty"`
+	// Add contains keys of tags to add to exported fields that don't have them, see golang.WithMissingTags.
+	Add []string `yaml:"add,omitempty"`
+	// Case is the case convention of added tags: camel (default), goCamel, snake or kebab.
+	Case string `yaml:"case,omitempty"`
+}
+
+var tagCases = map[string]golang.Case{
+	"":        golang.CamelCase,
+	"camel":   golang.CamelCase,
+	"goCamel": golang.GoCamelCase,
+	"snake":   golang.SnakeCase,
+	"kebab":   golang.KebabCase,
+}
+
+// ParseConfig parses a yaml configuration. Unknown keys are errors.
+func ParseConfig(src []byte) (*Config, error) {
+	c := &Config{}
+	if err := yaml.UnmarshalStrict(src, c); err != nil {
+		return nil, errors.Wrap(err, "parsing config")
+	}
+
+	return c, nil
+}
+
+// Options returns the options that format files as configured.
+func (c *Config) Options() ([]Option, error) {
+	goOpts, err := c.Go.options()
+	if err != nil {
+		return nil, err
+	}
+
+	yamlOpts, err := c.YAML.options()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []Option{WithGoOptions(goOpts...), WithYAMLOptions(yamlOpts...)}
+
+	for _, k := range c.YAML.KeyOrders {
+		keyOpts, err := k.options()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, path := range k.Paths {
+			opts = append(opts, WithYAMLFileOptions(path, keyOpts...))
+		}
+	}
+
+	for _, p := range c.Go.Pipelines {
+		for _, s := range p.Steps {
+			if !isGoStep(s) {
+				return nil, errors.Errorf("unknown go step %q", s)
+			}
+		}
+
+		for _, path := range p.Paths {
+			opts = append(opts, WithGoPipeline(path, p.Steps...))
+		}
+	}
+
+	return opts, nil
+}
+
+func isGoStep(s golang.Step) bool {
+	if s == golang.StepGofmt {
+		return true
+	}
+
+	for _, step := range golang.DefaultPipeline {
+		if s == step {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c GoConfig) options() ([]golang.Option, error) {
+	var opts []golang.Option
+
+	if len(c.LocalPrefixes) > 0 {
+		opts = append(opts, golang.WithLocalPrefixes(c.LocalPrefixes...))
+	}
+
+	if c.SideEffectImportsGroup {
+		opts = append(opts, golang.WithSideEffectImportsGroup)
+	}
+
+	if c.MaxLineLength > 0 {
+		opts = append(opts, golang.WithMaxLineLength(c.MaxLineLength))
+	}
+
+	if c.DocCommentWidth > 0 {
+		opts = append(opts, golang.WithDocComments(c.DocCommentWidth))
+	}
+
+	if len(c.Rewrite) > 0 {
+		rules, err := golang.ParseRules(c.Rewrite...)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing go rewrite rules")
+		}
+
+		opts = append(opts, golang.WithRewriteRules(rules...))
+	}
+
+	if c.EmbeddedLiterals {
+		opts = append(opts, golang.WithEmbeddedLiterals)
+	}
+
+	for kind, funcs := range c.LiteralCallSites {
+		opts = append(opts, golang.WithLiteralCallSites(kind, funcs...))
+	}
+
+	tagOpts, err := c.Tags.options()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(opts, tagOpts...), nil
+}
+
+func (c TagConfig) options() ([]golang.Option, error) {
+	var opts []golang.Option
+
+	if len(c.Order) > 0 {
+		opts = append(opts, golang.WithTagOrder(c.Order...))
+	}
+
+	if c.Align {
+		opts = append(opts, golang.WithTagAlignment)
+	}
+
+	if len(c.Add) > 0 {
+		tagCase, ok := tagCases[c.Case]
+		if !ok {
+			return nil, errors.Errorf("unknown tag case %q", c.Case)
+		}
+
+		opts = append(opts, golang.WithMissingTags(tagCase, c.Add...))
+	}
+
+	return opts, nil
+}
+
+func (c YAMLConfig) options() ([]yaml.EncodeOption, error) {
+	var opts []yaml.EncodeOption
+
+	if c.Indent > 0 {
+		opts = append(opts, yaml.Indent(c.Indent))
+	}
+
+	switch c.Sequences {
+	case "", "indented":
+	case "flush":
+		opts = append(opts, yaml.FlushSequences)
+	default:
+		return nil, errors.Errorf("unknown yaml sequence style %q", c.Sequences)
+	}
+
+	switch c.Quoting {
+	case "", "yaml1.2":
+	case "yaml1.1":
+		opts = append(opts, yaml.Quoting(yaml.YAML11Safe))
+	default:
+		return nil, errors.Errorf("unknown yaml quoting policy %q", c.Quoting)
+	}
+
+	switch c.Quotes {
+	case "", "single":
+	case "double":
+		opts = append(opts, yaml.PreferDoubleQuotes)
+	default:
+		return nil, errors.Errorf("unknown yaml quotes %q", c.Quotes)
+	}
+
+	if c.QuoteKeys {
+		opts = append(opts, yaml.QuoteKeys)
+	}
+
+	switch c.Anchors {
+	case "", "keep":
+	case "expand":
+		opts = append(opts, yaml.ExpandAliases)
+	case "hoist":
+		opts = append(opts, yaml.HoistAnchors)
+	default:
+		return nil, errors.Errorf("unknown yaml anchor handling %q", c.Anchors)
+	}
+
+	return opts, nil
+}
+
+func (c KeyOrderConfig) options() ([]yaml.EncodeOption, error) {
+	var opts []yaml.EncodeOption
+
+	if c.Profile != "" {
+		order, ok := yaml.KeyOrderProfiles[c.Profile]
+		if !ok {
+			return nil, errors.Errorf("unknown yaml key order profile %q", c.Profile)
+		}
+
+		opts = append(opts, yaml.OrderKeys(order))
+	}
+
+	if len(c.Keys) > 0 {
+		opts = append(opts, yaml.OrderKeys(c.Keys))
+	}
+
+	if c.Sort {
+		opts = append(opts, yaml.SortKeys)
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,167 @@
+package format_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format"
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	c, err := format.ParseConfig([]byte(`go:
+  rewrite:
+    - interface{} -> any
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"interface{} -> any"}, c.Go.Rewrite)
+
+	opts, err := c.Options()
+	require.NoError(t, err)
+
+	out, err := format.Format("foo.go", []byte("package foo\nvar Foo interface{}\n"), opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, "package foo\n\nvar Foo any\n", string(out))
+}
+
+func TestConfig_Error(t *testing.T) {
+	t.Parallel()
+
+	_, err := format.ParseConfig([]byte("go:\n  localPrefix: [foo]\n"))
+	assert.EqualError(t, err, `parsing config: 2:3: unknown field "localPrefix"`)
+
+	_, err = (&format.Config{Go: format.GoConfig{Rewrite: []string{"any"}}}).Options()
+	assert.EqualError(t, err,
+		`parsing go rewrite rules: rewrite rule "any" must be of the form 'pattern -> replacement'`)
+}
+
+func TestConfig_Tags(t *testing.T) {
+	t.Parallel()
+
+	c, err := format.ParseConfig([]byte(`go:
+  tags:
+    order: [json, yaml]
+    add: [yaml]
+    case: snake
+`))
+	require.NoError(t, err)
+
+	opts, err := c.Options()
+	require.NoError(t, err)
+
+	out, err := format.Format("foo.go",
+		[]byte("package foo\ntype Foo struct{ UserID int `yaml:\"id\" json:\"id\"`; UserName string }\n"), opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, "package foo\n\ntype Foo struct {\n"+
+		"\tUserID   int    `json:\"id\" yaml:\"id\"`\n"+
+		"\tUserName string `yaml:\"user_name\"`\n}\n", string(out))
+
+	_, err = (&format.Config{Go: format.GoConfig{Tags: format.TagConfig{Add: []string{"json"}, Case: "pascal"}}}).Options()
+	assert.EqualError(t, err, `unknown tag case "pascal"`)
+}
+
+func TestConfig_Pipelines(t *testing.T) {
+	t.Parallel()
+
+	c, err := format.ParseConfig([]byte(`go:
+  pipelines:
+    - paths: ["*.pb.go", "gen/*.go"]
+      steps: [imports]
+`))
+	require.NoError(t, err)
+
+	opts, err := c.Options()
+	require.NoError(t, err)
+
+	src := []byte("package foo\nvar a = 1\nvar b = 2\n")
+	imported := "package foo\n\nvar a = 1\nvar b = 2\n"
+	grouped := "package foo\n\nvar (\n\ta = 1\n\tb = 2\n)\n"
+
+	for path, want := range map[string]string{
+		"foo.pb.go":         imported,
+		"gen/foo.go":        imported,
+		"foo.go":            grouped,
+		"internal/gen/x.go": grouped,
+	} {
+		out, err := format.Format(path, src, opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(out), path)
+	}
+
+	_, err = (&format.Config{Go: format.GoConfig{Pipelines: []format.PipelineConfig{{
+		Paths: []string{"*.go"}, Steps: []golang.Step{"prettier"},
+	}}}}).Options()
+	assert.EqualError(t, err, `unknown go step "prettier"`)
+}
+
+func TestConfig_YAML(t *testing.T) {
+	t.Parallel()
+
+	c, err := format.ParseConfig([]byte(`yaml:
+  indent: 4
+  sequences: flush
+  quotes: double
+  anchors: expand
+`))
+	require.NoError(t, err)
+
+	opts, err := c.Options()
+	require.NoError(t, err)
+
+	out, err := format.Format("foo.yaml", []byte("a: &a\n  b:\n    - 'on'\n    - '1'\nc: *a\n"), opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, "a:\n    b:\n    - on\n    - \"1\"\nc:\n    b:\n    - on\n    - \"1\"\n", string(out))
+
+	opts, err = (&format.Config{YAML: format.YAMLConfig{Quoting: "yaml1.1", QuoteKeys: true}}).Options()
+	require.NoError(t, err)
+
+	out, err = format.Format("foo.yaml", []byte("on: push\nanswer: y\n"), opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, "'on': push\nanswer: 'y'\n", string(out))
+
+	_, err = (&format.Config{YAML: format.YAMLConfig{Sequences: "compact"}}).Options()
+	assert.EqualError(t, err, `unknown yaml sequence style "compact"`)
+
+	_, err = (&format.Config{YAML: format.YAMLConfig{Quoting: "yaml1.0"}}).Options()
+	assert.EqualError(t, err, `unknown yaml quoting policy "yaml1.0"`)
+
+	_, err = (&format.Config{YAML: format.YAMLConfig{Anchors: "inline"}}).Options()
+	assert.EqualError(t, err, `unknown yaml anchor handling "inline"`)
+}
+
+func TestConfig_YAMLKeyOrders(t *testing.T) {
+	t.Parallel()
+
+	c, err := format.ParseConfig([]byte(`yaml:
+  keyOrders:
+    - paths: [info.yaml]
+      profile: info
+    - paths: ["config/*.yaml"]
+      keys:
+        $: [name]
+      sort: true
+`))
+	require.NoError(t, err)
+
+	opts, err := c.Options()
+	require.NoError(t, err)
+
+	src := []byte("team: a\nversion: 1\nname: foo\n")
+
+	for path, want := range map[string]string{
+		"info.yaml":        "name: foo\nversion: 1\nteam: a\n",
+		"config/foo.yaml":  "name: foo\nteam: a\nversion: 1\n",
+		"other/info.yaml":  "name: foo\nversion: 1\nteam: a\n",
+		"other/other.yaml": string(src),
+	} {
+		out, err := format.Format(path, src, opts...)
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(out), path)
+	}
+
+	_, err = (&format.Config{YAML: format.YAMLConfig{KeyOrders: []format.KeyOrderConfig{{Profile: "helm"}}}}).Options()
+	assert.EqualError(t, err, `unknown yaml key order profile "helm"`)
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 
 	"github.com/faetools/format/golang"
+	"github.com/faetools/format/gotemplate"
 	"github.com/faetools/format/markdown"
 	"github.com/faetools/format/yaml"
 	"github.com/faetools/kit/terminal"
@@ -27,15 +28,44 @@ func init() {
 }
 
 // Format formats contents according to type.
-func Format(path string, src []byte) (out []byte, err error) {
+func Format(path string, src []byte, opts ...Option) (out []byte, err error) {
+	o := newOptions(opts)
+
 	ext := filepath.Ext(path)
+	goOpts := o.goOptions[:len(o.goOptions):len(o.goOptions)]
+	yamlOpts := o.yamlOptionsFor(path)
+
+	if o.fsys != nil {
+		goOpts = append(goOpts, golang.WithFS(o.fsys))
+	}
+
+	if o.generatedPolicy != FormatGenerated && IsGeneratedFS(o.fsys, path, src, o.generatedPatterns...) {
+		switch {
+		case o.generatedPolicy == SkipGenerated:
+			return src, nil
+		case ext == ".go":
+			goOpts = append(goOpts, golang.WithoutExtraRules)
+		case ext == ".yml", ext == ".yaml":
+			yamlOpts = nil
+		case ext == ".json":
+		default:
+			return src, nil
+		}
+	}
+
+	if steps, ok := o.goPipeline(path); ok {
+		goOpts = append(goOpts, golang.WithPipeline(steps...))
+	}
+
 	switch ext {
 	case ".go":
-		src, err = golang.Format(path, src)
+		src, err = golang.Format(path, src, goOpts...)
 	case ".yml", ".yaml":
-		src, err = yaml.Format(src)
+		src, err = yaml.Format(src, yamlOpts...)
 	case ".md":
 		src, err = markdown.Format(src)
+	case ".tmpl", ".gotmpl":
+		src, err = gotemplate.Format(src)
 	case ".json":
 		src = json.PrettyOptions(src, &json.Options{Indent: "  "})
 	case "":
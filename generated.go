@@ -0,0 +1,116 @@
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GeneratedPolicy defines how generated files are formatted.
+type GeneratedPolicy int
+
+const (
+	// FormatGenerated formats generated files like any other file.
+	FormatGenerated GeneratedPolicy = iota
+	// SkipGenerated leaves generated files untouched.
+	SkipGenerated
+	// LightFormatGenerated only applies light formatting to generated files:
+	// go code is formatted without gofumpt's extra rules, yaml files without the yaml options (e.g. key orders)
+	// and json files are pretty-printed as usual. Markdown and all other files are left untouched.
+	LightFormatGenerated
+)
+
+// GeneratedSidecarSuffix is the suffix of a file that marks the file without the suffix as generated.
+// It is used for file types that don't support comments, e.g. "openapi.json.generated" marks "openapi.json".
+const GeneratedSidecarSuffix = ".generated"
+
+var (
+	// See https://go.dev/s/generatedcode.
+	rxGoGenerated       = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+	rxYAMLGenerated     = regexp.MustCompile(`^# Code generated .* DO NOT EDIT\.$`)
+	rxMarkdownGenerated = regexp.MustCompile(`^<!-- Code generated .* DO NOT EDIT\. -->$`)
+
+	// DefaultGeneratedPatterns are the file name patterns of generated files.
+	DefaultGeneratedPatterns = []string{"*.gen.*", "*.generated.*"}
+)
+
+// IsGenerated reports whether the file at path is generated.
+// This is the case if the name matches any of the patterns (see filepath.Match),
+// if the file starts with a "Code generated ... DO NOT EDIT." comment
+// or if a sidecar file exists on disk (see GeneratedSidecarSuffix).
+func IsGenerated(path string, src []byte, patterns ...string) bool {
+	return IsGeneratedFS(nil, path, src, patterns...)
+}
+
+// IsGeneratedFS is like IsGenerated but looks for the sidecar file in fsys, or on disk if fsys is nil.
+func IsGeneratedFS(fsys fs.FS, path string, src []byte, patterns ...string) bool {
+	name := filepath.Base(path)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	switch filepath.Ext(path) {
+	case ".go":
+		if hasGeneratedHeader(src, rxGoGenerated, "//") {
+			return true
+		}
+	case ".yml", ".yaml":
+		if hasGeneratedHeader(src, rxYAMLGenerated, "#", "%", "---") {
+			return true
+		}
+	case ".md":
+		if hasGeneratedHeader(src, rxMarkdownGenerated, "<!--") {
+			return true
+		}
+	}
+
+	return hasSidecar(fsys, path)
+}
+
+// hasSidecar reports whether the sidecar file of the file at path exists in fsys, or on disk if fsys is nil.
+func hasSidecar(fsys fs.FS, path string) bool {
+	if fsys == nil {
+		_, err := os.Stat(path + GeneratedSidecarSuffix)
+		return err == nil
+	}
+
+	_, err := fs.Stat(fsys, path+GeneratedSidecarSuffix)
+
+	return err == nil
+}
+
+// hasGeneratedHeader reports whether a line matching rx is found before the first line
+// that is neither empty nor starts with any of the prefixes.
+func hasGeneratedHeader(src []byte, rx *regexp.Regexp, prefixes ...string) bool {
+	s := bufio.NewScanner(bytes.NewReader(src))
+
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \t\r")
+
+		switch {
+		case rx.MatchString(line):
+			return true
+		case line == "", hasAnyPrefix(line, prefixes):
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+package format_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/faetools/format"
+	"github.com/faetools/format/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGenerated(t *testing.T) {
+	t.Parallel()
+
+	for i, tt := range []struct {
+		path, src string
+		generated bool
+	}{
+		{"foo.go", "package foo\n", false},
+		{"foo.go", "// Code generated by test; DO NOT EDIT.\npackage foo\n", true},
+		{"foo.go", "// Package foo does foo.\n//\n// Code generated by xxx DO NOT EDIT.\npackage foo\n", true},
+		{"foo.go", "package foo\n\n// Code generated by test; DO NOT EDIT.\n", false},
+		{"foo.go", "// Code generated by test; DO NOT EDIT\npackage foo\n", false},
+		{"version.gen.go", "package foo\n", true},
+		{"foo.yaml", "# Code generated by devtool; DO NOT EDIT.\nfoo: bar\n", true},
+		{"foo.yml", "---\n# Code generated by devtool; DO NOT EDIT.\nfoo: bar\n", true},
+		{"foo.yaml", "foo: bar\n# Code generated by devtool; DO NOT EDIT.\n", false},
+		{"README.md", "<!-- Code generated by devtool; DO NOT EDIT. -->\n# Title\n", true},
+		{"README.md", "# Title\n", false},
+		{"openapi.json", "{}", false},
+		{"openapi.gen.json", "{}", true},
+		{"openapi.generated.json", "{}", true},
+	} {
+		i, tt := i, tt
+		t.Run(fmt.Sprintf("#%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.generated,
+				format.IsGenerated(tt.path, []byte(tt.src), format.DefaultGeneratedPatterns...))
+		})
+	}
+}
+
+func TestIsGenerated_Sidecar(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "openapi.json")
+	assert.False(t, format.IsGenerated(path, []byte("{}")))
+
+	assert.NoError(t, os.WriteFile(path+format.GeneratedSidecarSuffix, nil, 0o600))
+	assert.True(t, format.IsGenerated(path, []byte("{}")))
+}
+
+func TestFormat_GeneratedPolicy(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`// Code generated by test; DO NOT EDIT.
+package foo
+
+func foo(a int, b int) {}
+`)
+
+	out, err := format.Format("foo.go", src)
+	assert.NoError(t, err)
+	assert.Equal(t, "// Code generated by test; DO NOT EDIT.\npackage foo\n\nfunc foo(a, b int) {}\n", string(out))
+
+	out, err = format.Format("foo.go", src, format.WithGeneratedPolicy(format.SkipGenerated))
+	assert.NoError(t, err)
+	assert.Equal(t, string(src), string(out))
+
+	out, err = format.Format("foo.go", src, format.WithGeneratedPolicy(format.LightFormatGenerated))
+	assert.NoError(t, err)
+	assert.Equal(t, "// Code generated by test; DO NOT EDIT.\npackage foo\n\nfunc foo(a int, b int) {}\n", string(out))
+
+	yml := []byte("# Code generated by test; DO NOT EDIT.\nfoo:   \"bar\"\n")
+
+	out, err = format.Format("foo.yml", yml, format.WithGeneratedPolicy(format.LightFormatGenerated),
+		format.WithYAMLOptions(yaml.Indent(4)))
+	assert.NoError(t, err)
+	assert.Equal(t, "# Code generated by test; DO NOT EDIT.\nfoo: bar\n", string(out))
+
+	md := []byte("<!-- Code generated by test; DO NOT EDIT. -->\n# Foo\n* a\n")
+
+	out, err = format.Format("foo.md", md, format.WithGeneratedPolicy(format.LightFormatGenerated))
+	assert.NoError(t, err)
+	assert.Equal(t, string(md), string(out))
+}
+
+func TestFormat_GeneratedSidecarFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{"api/openapi.json.generated": {}}
+
+	assert.True(t, format.IsGeneratedFS(fsys, "api/openapi.json", []byte("{}")))
+	assert.False(t, format.IsGeneratedFS(fsys, "api/other.json", []byte("{}")))
+
+	out, err := format.Format("api/openapi.json", []byte(`{"a":1}`),
+		format.WithFS(fsys), format.WithGeneratedPolicy(format.SkipGenerated))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(out))
+
+	out, err = format.Format("api/openapi.json", []byte(`{"a":1}`),
+		format.WithFS(fsys), format.WithGeneratedPolicy(format.LightFormatGenerated))
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": 1\n}\n", string(out))
+}
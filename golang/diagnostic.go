@@ -0,0 +1,29 @@
+package golang
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// A Diagnostic is a problem found while formatting.
+type Diagnostic struct {
+	Pos     token.Position
+	Message string
+}
+
+// String returns the diagnostic in the form "file:line:column: message".
+func (d Diagnostic) String() string { return fmt.Sprintf("%s: %s", d.Pos, d.Message) }
+
+// WithDiagnostics reports any problems found while formatting to the given function.
+// Positions refer to the formatted code.
+func WithDiagnostics(report func(Diagnostic)) Option {
+	return func(o *options) {
+		o.report = report
+	}
+}
+
+func (o *options) reportf(pos token.Position, format string, args ...interface{}) {
+	if o.report != nil {
+		o.report(Diagnostic{Pos: pos, Message: fmt.Sprintf(format, args...)})
+	}
+}
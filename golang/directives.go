@@ -0,0 +1,173 @@
+package golang
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// rxSpacedDirective matches comments that have a space after the comment marker and look like a directive,
+// which would therefore be ignored.
+var rxSpacedDirective = regexp.MustCompile(`^//\s+(go:[a-z]+|nolint)\b(.*)$`)
+
+// rxNolint matches the arguments of a valid nolint directive, e.g. ":gomnd,lll // explanation".
+var rxNolint = regexp.MustCompile(`^(:[\w-]+(,[\w-]+)*)?(\s+//.*)?$`)
+
+// Compiler directives that take no arguments and must be placed above a function.
+var funcDirectives = map[string]bool{
+	"go:noinline": true, "go:nosplit": true, "go:noescape": true, "go:norace": true,
+	"go:nocheckptr": true, "go:uintptrescapes": true,
+}
+
+// normalizeDirectives removes any whitespace between the comment marker and a directive,
+// e.g. "// go:generate" becomes "//go:generate".
+// Only comments that are valid directives at their position are changed so that prose like
+// "// go:generate is run by make" stays as it is.
+// Legacy "// +build" lines are converted afterwards by gofmt, which adds an equivalent "//go:build" line
+// and keeps both in sync for older toolchains.
+func normalizeDirectives(src []byte) []byte {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		// Parsing errors are reported when running 'imports'.
+		return src
+	}
+
+	docs := declDocs(f)
+	b := &bytes.Buffer{}
+	last := 0
+
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			m := rxSpacedDirective.FindStringSubmatchIndex(c.Text)
+			if m == nil || !isDirective(c.Text[m[2]:m[3]], c.Text[m[4]:], cg, docs[cg]) {
+				continue
+			}
+
+			start := fset.Position(c.Pos()).Offset
+
+			b.Write(src[last:start])
+			b.WriteString("//" + c.Text[m[2]:])
+
+			last = start + len(c.Text)
+		}
+	}
+
+	if last == 0 {
+		return src
+	}
+
+	b.Write(src[last:])
+
+	return b.Bytes()
+}
+
+// isDirective reports whether name followed by args is a valid directive in the comment group cg,
+// which documents decl (a declaration or value spec) if it isn't nil.
+func isDirective(name, args string, cg *ast.CommentGroup, decl ast.Node) bool {
+	fields := strings.Fields(args)
+
+	switch {
+	case name == "nolint":
+		return rxNolint.MatchString(args)
+	case name == "go:build":
+		_, err := constraint.Parse("//go:build" + args)
+
+		return err == nil
+	case name == "go:generate":
+		// Generate directives may be anywhere but are never part of a sentence.
+		return len(fields) > 0 && len(cg.List) == 1
+	case name == "go:embed":
+		// Embedded variables must not be initialised.
+		s, ok := decl.(*ast.ValueSpec)
+
+		return len(fields) > 0 && ok && s.Type != nil && len(s.Values) == 0
+	case name == "go:linkname":
+		return len(fields) == 1 || len(fields) == 2
+	case funcDirectives[name]:
+		_, ok := decl.(*ast.FuncDecl)
+
+		return len(fields) == 0 && ok
+	default:
+		return false
+	}
+}
+
+// declDocs returns the declarations or, for variables and constants, the specs documented by each doc comment in f.
+func declDocs(f *ast.File) map[*ast.CommentGroup]ast.Node {
+	docs := map[*ast.CommentGroup]ast.Node{}
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				docs[d.Doc] = d
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				docs[d.Doc] = d
+				if !d.Lparen.IsValid() && len(d.Specs) == 1 {
+					docs[d.Doc] = d.Specs[0]
+				}
+			}
+
+			for _, s := range d.Specs {
+				if s, ok := s.(*ast.ValueSpec); ok && s.Doc != nil {
+					docs[s.Doc] = s
+				}
+			}
+		}
+	}
+
+	return docs
+}
+
+// checkEmbeds reports any //go:embed directive that is not directly above a var declaration.
+func (o *options) checkEmbeds(src []byte) {
+	if o.report == nil || !bytes.Contains(src, []byte("//go:embed")) {
+		return
+	}
+
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, o.filename, src, parser.ParseComments)
+	if err != nil {
+		return
+	}
+
+	// The doc comments of variables.
+	varDocs := map[*ast.CommentGroup]bool{}
+
+	for _, decl := range f.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.VAR {
+			continue
+		}
+
+		if !d.Lparen.IsValid() {
+			varDocs[d.Doc] = true
+			continue
+		}
+
+		for _, s := range d.Specs {
+			varDocs[s.(*ast.ValueSpec).Doc] = true //nolint:forcetypeassert // var declarations only have value specs
+		}
+	}
+
+	for _, cg := range f.Comments {
+		if varDocs[cg] {
+			continue
+		}
+
+		for _, c := range cg.List {
+			if strings.HasPrefix(c.Text, "//go:embed ") {
+				o.reportf(fset.Position(c.Pos()), "//go:embed directive must be directly above a var declaration")
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat_BuildConstraints(t *testing.T) {
+	t.Parallel()
+
+	for src, want := range map[string]string{
+		"// +build linux,amd64 darwin\n\npackage foo\n": "//go:build (linux && amd64) || darwin\n" +
+			"// +build linux,amd64 darwin\n\npackage foo\n",
+		"//go:build linux\n// +build darwin\n\npackage foo\n": "//go:build linux\n// +build linux\n\npackage foo\n",
+		"// go:build linux\n\npackage foo\n":                  "//go:build linux\n\npackage foo\n",
+	} {
+		out, err := golang.Format("foo.go", []byte(src))
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(out))
+	}
+}
+
+const (
+	spacedDirectives = `package foo
+
+import _ "embed"
+
+// go:generate echo foo
+
+// X is embedded.
+//  go:embed foo.txt
+var X string
+
+// Go: this is not a directive.
+var y = 1 // nolint:gomnd
+
+//go:embed bar.txt
+
+var z string
+`
+
+	normalizedDirectives = `package foo
+
+import _ "embed"
+
+//go:generate echo foo
+
+// X is embedded.
+//
+//go:embed foo.txt
+var X string
+
+// Go: this is not a directive.
+var y = 1 //nolint:gomnd
+
+//go:embed bar.txt
+
+var z string
+`
+)
+
+func TestFormat_Directives(t *testing.T) {
+	t.Parallel()
+
+	var diagnostics []string
+
+	out, err := golang.Format("foo.go", []byte(spacedDirectives), golang.WithDiagnostics(func(d golang.Diagnostic) {
+		diagnostics = append(diagnostics, d.String())
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, normalizedDirectives, string(out))
+	assert.Equal(t, []string{
+		"foo.go:15:1: //go:embed directive must be directly above a var declaration",
+	}, diagnostics)
+}
+
+func TestFormat_DirectivesProse(t *testing.T) {
+	t.Parallel()
+
+	src := `package foo
+
+// Foo is run by
+// go:generate when the API changes.
+//
+// go:build tags are not needed.
+func Foo() {}
+
+// go:embed is not used for
+var bar = 1 // nolint is not needed here
+
+// go:noinline
+var baz = 2
+`
+
+	out, err := golang.Format("foo.go", []byte(src))
+	assert.NoError(t, err)
+	assert.Equal(t, src, string(out))
+}
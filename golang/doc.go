@@ -0,0 +1,268 @@
+//go:build go1.19
+
+package golang
+
+import (
+	"bytes"
+	"go/ast"
+	"go/doc/comment"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	gofumpt "mvdan.cc/gofumpt/format"
+)
+
+var (
+	rxDirective     = regexp.MustCompile(`^//(line |extern |export |nolint|[a-z0-9]+:[a-z0-9])`)
+	rxMarkdownLink  = regexp.MustCompile(`\[([^\[\]]+)\]\((https?://[^()\s]+)\)`)
+	rxListItemStart = regexp.MustCompile(`^( +)([-*+•]|[0-9]+[.)]) `)
+)
+
+// formatDocComments reformats the doc comments of all exported declarations.
+func (o *options) formatDocComments(src []byte) ([]byte, error) {
+	if o.docCommentWidth <= 0 {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing")
+	}
+
+	b := &bytes.Buffer{}
+	last := 0
+
+	for _, cg := range exportedDocs(f) {
+		if strings.HasPrefix(cg.List[0].Text, "/*") {
+			continue
+		}
+
+		start, end := fset.Position(cg.Pos()).Offset, fset.Position(cg.End()).Offset
+		indent := src[bytes.LastIndexByte(src[:start], '\n')+1 : start]
+
+		b.Write(src[last:start])
+		b.WriteString(strings.Join(formatDocComment(cg, o.docCommentWidth), "\n"+string(indent)))
+
+		last = end
+	}
+
+	if last == 0 {
+		return src, nil
+	}
+
+	b.Write(src[last:])
+
+	res, err := gofumpt.Source(b.Bytes(), o.gofumpt)
+
+	return res, errors.Wrap(err, "formatting doc comments")
+}
+
+// exportedDocs returns the doc comments of all exported declarations in the order they appear.
+func exportedDocs(f *ast.File) (docs []*ast.CommentGroup) {
+	add := func(doc *ast.CommentGroup, name *ast.Ident) {
+		if doc != nil && name.IsExported() {
+			docs = append(docs, doc)
+		}
+	}
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			add(d.Doc, d.Name)
+		case *ast.GenDecl:
+			if d.Doc != nil && isExportedDecl(d) {
+				docs = append(docs, d.Doc)
+			}
+
+			if !d.Lparen.IsValid() {
+				continue
+			}
+
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.TypeSpec:
+					add(s.Doc, s.Name)
+				case *ast.ValueSpec:
+					add(s.Doc, s.Names[0])
+				}
+			}
+		}
+	}
+
+	return docs
+}
+
+func isExportedDecl(d *ast.GenDecl) bool {
+	for _, s := range d.Specs {
+		switch s := s.(type) {
+		case *ast.TypeSpec:
+			if s.Name.IsExported() {
+				return true
+			}
+		case *ast.ValueSpec:
+			for _, n := range s.Names {
+				if n.IsExported() {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// formatDocComment returns the lines of the reformatted doc comment.
+// Directives are kept as they are and placed at the end, like gofmt does.
+func formatDocComment(cg *ast.CommentGroup, width int) []string {
+	var text, directives []string
+
+	for _, c := range cg.List {
+		if rxDirective.MatchString(c.Text) {
+			directives = append(directives, c.Text)
+			continue
+		}
+
+		line := strings.TrimPrefix(c.Text, "//")
+		text = append(text, strings.TrimPrefix(line, " "))
+	}
+
+	var (
+		lines []string
+		p     comment.Parser
+	)
+
+	if doc := p.Parse(convertMarkdownLinks(strings.Join(text, "\n"))); len(doc.Content) > 0 {
+		lines = wrapDocComment(string((&comment.Printer{}).Comment(doc)), width)
+	}
+
+	if len(directives) == 0 {
+		return lines
+	}
+
+	if len(lines) > 0 {
+		lines = append(lines, "//")
+	}
+
+	return append(lines, directives...)
+}
+
+// convertMarkdownLinks converts links like [text](url) into doc links with a link definition at the end.
+func convertMarkdownLinks(text string) string {
+	var defs []string
+
+	text = rxMarkdownLink.ReplaceAllStringFunc(text, func(s string) string {
+		m := rxMarkdownLink.FindStringSubmatch(s)
+		defs = append(defs, "["+m[1]+"]: "+m[2])
+
+		return "[" + m[1] + "]"
+	})
+
+	if len(defs) == 0 {
+		return text
+	}
+
+	return text + "\n\n" + strings.Join(defs, "\n")
+}
+
+// wrapDocComment wraps the paragraphs and list items of a doc comment in canonical form
+// and returns the lines with comment markers.
+func wrapDocComment(c string, width int) (lines []string) {
+	var (
+		words   []string
+		prefix  string // The prefix of the first line.
+		hanging string // The prefix of all other lines.
+	)
+
+	flush := func() {
+		lines = append(lines, wrapWords(words, prefix, hanging, width)...)
+		words = nil
+	}
+
+	for _, text := range strings.Split(strings.TrimSuffix(c, "\n"), "\n") {
+		switch {
+		case text == "", strings.HasPrefix(text, "\t"):
+			// Blank lines and code blocks stay as they are.
+			flush()
+
+			lines = append(lines, "//"+text)
+		case strings.HasPrefix(text, "# "), strings.HasPrefix(text, "[") && strings.Contains(text, "]: "):
+			// Headings and link definitions stay as they are.
+			flush()
+
+			lines = append(lines, "// "+text)
+		case rxListItemStart.MatchString(text):
+			flush()
+
+			m := rxListItemStart.FindStringSubmatch(text)
+			prefix = "// " + m[0]
+			hanging = "// " + strings.Repeat(" ", len(m[0]))
+			words = strings.Fields(text[len(m[0]):])
+		case strings.HasPrefix(text, " "):
+			// Continuation of a list item.
+			if len(words) == 0 {
+				prefix = "// " + text[:len(text)-len(strings.TrimLeft(text, " "))]
+				hanging = prefix
+			}
+
+			words = append(words, strings.Fields(text)...)
+		default:
+			if len(words) == 0 {
+				prefix, hanging = "// ", "// "
+			}
+
+			words = append(words, strings.Fields(text)...)
+		}
+	}
+
+	flush()
+
+	return lines
+}
+
+// wrapWords puts as many words on each line as fit into the width, keeping bracketed links together.
+func wrapWords(words []string, prefix, hanging string, width int) (lines []string) {
+	if len(words) == 0 {
+		return nil
+	}
+
+	line := &strings.Builder{}
+	line.WriteString(prefix)
+
+	length := textLength(prefix)
+	depth := 0
+
+	for i, word := range words {
+		// Don't break inside of a link.
+		breakable := i > 0 && depth == 0
+		depth += strings.Count(word, "[") - strings.Count(word, "]")
+
+		switch {
+		case breakable && length+1+utf8.RuneCountInString(word) > width:
+			lines = append(lines, line.String())
+
+			line.Reset()
+			line.WriteString(hanging)
+
+			length = textLength(hanging)
+		case i > 0:
+			line.WriteByte(' ')
+			length++
+		}
+
+		line.WriteString(word)
+		length += utf8.RuneCountInString(word)
+	}
+
+	return append(lines, line.String())
+}
+
+// textLength returns the length of a line prefix without the comment marker.
+func textLength(prefix string) int {
+	return utf8.RuneCountInString(prefix) - len("// ")
+}
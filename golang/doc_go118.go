@@ -0,0 +1,8 @@
+//go:build !go1.19
+
+package golang
+
+// formatDocComments leaves the doc comments as they are since go/doc/comment requires Go 1.19.
+func (o *options) formatDocComments(src []byte) ([]byte, error) {
+	return src, nil
+}
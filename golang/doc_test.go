@@ -0,0 +1,67 @@
+//go:build go1.19
+
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	docComments = `package foo
+
+// Foo does a lot of things and this sentence is very long, so it has to be wrapped somewhere around here.
+// It is documented on [the website](https://example.com/foo) and works like [Bar].
+// The following is a list:
+//  - one
+//  - two is also a quite long item, which needs to be wrapped as well
+//
+// Example:
+//     Foo()
+//
+//go:generate echo foo
+//nolint:gomnd // not a magic number
+func Foo() {}
+
+// bar is not exported, so its very long doc comment that goes on and on stays the way it is.
+func bar() {}
+`
+
+	formattedDocComments = `package foo
+
+// Foo does a lot of things and this sentence is very
+// long, so it has to be wrapped somewhere around
+// here. It is documented on [the website] and works
+// like [Bar]. The following is a list:
+//   - one
+//   - two is also a quite long item, which needs to
+//     be wrapped as well
+//
+// Example:
+//
+//	Foo()
+//
+// [the website]: https://example.com/foo
+//
+//go:generate echo foo
+//nolint:gomnd // not a magic number
+func Foo() {}
+
+// bar is not exported, so its very long doc comment that goes on and on stays the way it is.
+func bar() {}
+`
+)
+
+func TestFormat_DocComments(t *testing.T) {
+	t.Parallel()
+
+	out, err := golang.Format("", []byte(docComments), golang.WithDocComments(50))
+	assert.NoError(t, err)
+	assert.Equal(t, formattedDocComments, string(out))
+
+	out, err = golang.Format("", out, golang.WithDocComments(50))
+	assert.NoError(t, err)
+	assert.Equal(t, formattedDocComments, string(out))
+}
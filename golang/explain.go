@@ -0,0 +1,270 @@
+package golang
+
+import (
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	gofumpt "mvdan.cc/gofumpt/format"
+)
+
+// An Edit is a change made while formatting.
+type Edit struct {
+	// Step is the formatting step that made the change, e.g. "imports" or "gofumpt".
+	Step Step
+	// Rule describes the change, e.g. "removed blank lines" or "applied gofumpt's extra rules".
+	Rule string
+	// Pos is the position of the change in the code before the step.
+	Pos token.Position
+	// Before and After are the lines that were replaced.
+	Before, After []string
+}
+
+// String returns the edit in the form "file:line: rule (step)".
+func (e Edit) String() string { return fmt.Sprintf("%s: %s (%s)", e.Pos, e.Rule, e.Step) }
+
+// Explain formats golang code like Format and also returns the edits that were made by each step.
+// Plain gofmt changes are split from the changes of gofumpt by running gofmt first and gofumpt's extra rules are
+// applied separately so that each edit is named after the step that made it.
+func Explain(filepath string, src []byte, opts ...Option) ([]byte, []Edit, error) {
+	o := newOptions(opts)
+	o.filename = filepath
+
+	src, err := o.readSource(filepath, src)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if src == nil {
+		if src, err = os.ReadFile(filepath); err != nil {
+			return nil, nil, errors.Wrapf(err, "reading %s", filepath)
+		}
+	}
+
+	steps := o.steps()
+
+	if o.runs(StepGofumpt) && !o.runs(StepGofmt) {
+		steps = append([]step{{StepGofmt, func(src []byte) ([]byte, error) {
+			// Parsing errors are reported when running 'imports'.
+			if res, err := format.Source(src); err == nil {
+				return res, nil
+			}
+
+			return src, nil
+		}}}, steps...)
+	}
+
+	var edits []Edit
+
+	run := func(name Step, rule string, fn func(src []byte) ([]byte, error)) error {
+		res, err := fn(src)
+		if err != nil {
+			return err
+		}
+
+		if name == StepImports {
+			rule = importsRule(src, res)
+		}
+
+		edits = append(edits, diffEdits(filepath, name, rule, src, res)...)
+		src = res
+
+		return nil
+	}
+
+	for _, s := range steps {
+		rule := stepRule(s.name)
+
+		if s.name == StepGofumpt && o.gofumpt.ExtraRules {
+			base := o.gofumpt
+			base.ExtraRules = false
+
+			if err := run(s.name, rule, func(src []byte) ([]byte, error) { return gofumpt.Source(src, base) }); err != nil {
+				return nil, nil, err
+			}
+
+			rule = "applied gofumpt's extra rules"
+		}
+
+		if err := run(s.name, rule, s.run); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	o.checkEmbeds(src)
+
+	return src, edits, nil
+}
+
+// stepRule describes the changes of a step that are not only whitespace.
+func stepRule(s Step) string {
+	switch s {
+	case StepGofmt:
+		return "formatted like gofmt"
+	case StepGofumpt:
+		return "applied gofumpt's rules"
+	default:
+		return string(s)
+	}
+}
+
+// diffEdits returns the edits a step made by comparing the lines before and after.
+//
+// The rule of edits that don't only change whitespace is the given one.
+func diffEdits(filepath string, step Step, rule string, before, after []byte) (edits []Edit) {
+	if string(before) == string(after) {
+		return nil
+	}
+
+	a := strings.Split(string(before), "\n")
+	b := strings.Split(string(after), "\n")
+
+	add := func(i1, i2, j1, j2 int) {
+		e := Edit{
+			Step:   step,
+			Pos:    token.Position{Filename: filepath, Line: i1 + 1},
+			Before: a[i1:i2],
+			After:  b[j1:j2],
+		}
+		e.Rule = explain(rule, e.Before, e.After)
+
+		edits = append(edits, e)
+	}
+
+	// Compare the lines without indentation so that changed indentation doesn't hide other changes.
+	ops := difflib.NewMatcher(trimLines(a), trimLines(b)).GetOpCodes()
+
+	for _, op := range ops {
+		if op.Tag == 'e' {
+			// Report lines that only differ in whitespace.
+			for k := 0; k < op.I2-op.I1; k++ {
+				start := k
+				for k < op.I2-op.I1 && a[op.I1+k] != b[op.J1+k] {
+					k++
+				}
+
+				if k > start {
+					add(op.I1+start, op.I1+k, op.J1+start, op.J1+k)
+				}
+			}
+
+			continue
+		}
+
+		add(op.I1, op.I2, op.J1, op.J2)
+	}
+
+	return edits
+}
+
+var rxTrailingSpace = regexp.MustCompile(`\S\s+$`)
+
+// explain returns a human-readable reason for replacing the lines with the given rule of the step.
+func explain(rule string, before, after []string) string {
+	if !equalTrimmed(before, after) {
+		switch {
+		case blankLines(before) > blankLines(after):
+			return "removed blank lines and " + rule
+		case blankLines(before) < blankLines(after):
+			return "added blank lines and " + rule
+		default:
+			return rule
+		}
+	}
+
+	switch {
+	case blankLines(before) > blankLines(after):
+		return "removed blank lines"
+	case blankLines(before) < blankLines(after):
+		return "added blank lines"
+	case anyMatch(rxTrailingSpace, before):
+		return "removed trailing whitespace"
+	default:
+		return "fixed indentation"
+	}
+}
+
+// equalTrimmed reports whether the lines only differ in whitespace and blank lines.
+func equalTrimmed(a, b []string) bool {
+	return strings.Join(strings.Fields(strings.Join(a, "\n")), " ") ==
+		strings.Join(strings.Fields(strings.Join(b, "\n")), " ")
+}
+
+func trimLines(lines []string) []string {
+	trimmed := make([]string, len(lines))
+	for i, l := range lines {
+		trimmed[i] = strings.TrimSpace(l)
+	}
+
+	return trimmed
+}
+
+func blankLines(lines []string) (n int) {
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			n++
+		}
+	}
+
+	return n
+}
+
+// importsRule explains the changes fixing the imports made to src.
+func importsRule(src, fixed []byte) string {
+	old, now := importPaths(src), importPaths(fixed)
+
+	added, removed := false, false
+	for p := range now {
+		added = added || !old[p]
+	}
+
+	for p := range old {
+		removed = removed || !now[p]
+	}
+
+	switch {
+	case added && removed:
+		return "added missing and removed unused imports"
+	case added:
+		return "added missing imports"
+	case removed:
+		return "removed unused imports"
+	default:
+		return "sorted imports"
+	}
+}
+
+func anyMatch(rx *regexp.Regexp, lines []string) bool {
+	for _, l := range lines {
+		if rx.MatchString(l) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// importPaths returns the paths of all imports in src.
+func importPaths(src []byte) map[string]bool {
+	paths := map[string]bool{}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return paths
+	}
+
+	for _, imp := range f.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err == nil {
+			paths[p] = true
+		}
+	}
+
+	return paths
+}
@@ -0,0 +1,56 @@
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+func explanations(edits []golang.Edit) []string {
+	res := make([]string, len(edits))
+	for i, e := range edits {
+		res[i] = e.String()
+	}
+
+	return res
+}
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	out, edits, err := golang.Explain("foo.go", []byte(before))
+	assert.NoError(t, err)
+	assert.Equal(t, after, string(out))
+	assert.Equal(t, []string{
+		"foo.go:2: fixed indentation (gofmt)",
+		"foo.go:4: fixed indentation (gofmt)",
+		"foo.go:7: removed blank lines (gofmt)",
+		"foo.go:10: fixed indentation (gofmt)",
+		"foo.go:4: applied gofumpt's rules (gofumpt)",
+	}, explanations(edits))
+
+	out, edits, err = golang.Explain("foo.go", []byte(`package foo
+
+import "os"
+
+func foo() {
+
+	fmt.Println(0755)
+}
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "package foo\n\nimport \"fmt\"\n\nfunc foo() {\n\tfmt.Println(0o755)\n}\n", string(out))
+	assert.Equal(t, []string{
+		"foo.go:3: added missing and removed unused imports (imports)",
+		"foo.go:6: removed blank lines and applied gofumpt's rules (gofumpt)",
+	}, explanations(edits))
+
+	out, edits, err = golang.Explain("foo.go", []byte("package foo\n\nfunc foo(a int, b int) {\n\n}\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package foo\n\nfunc foo(a, b int) {\n}\n", string(out))
+	assert.Equal(t, []string{
+		"foo.go:4: removed blank lines (gofumpt)",
+		"foo.go:3: applied gofumpt's extra rules (gofumpt)",
+	}, explanations(edits))
+}
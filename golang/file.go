@@ -0,0 +1,225 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/faetools/format/writers"
+)
+
+// File builds a go source file that is formatted when rendered.
+//
+// Imports are tracked and aliased automatically if their names collide:
+//
+//	f := golang.NewFile("foo")
+//	f.Func("", "Wrap(err error) error", func(w writers.Writer) {
+//		fmt.Fprintf(w, "return %s(err, \"foo\")", f.Qual("github.com/pkg/errors", "Wrap"))
+//	})
+type File struct {
+	pkg    string
+	notice string
+	doc    string
+
+	imports map[string]string // The names of all imported paths.
+	names   map[string]string // The paths of all used import names.
+
+	body bytes.Buffer
+}
+
+// NewFile returns a new file of the given package.
+func NewFile(pkg string) *File {
+	return &File{pkg: pkg, imports: map[string]string{}, names: map[string]string{}}
+}
+
+// GeneratedNotice marks the file as generated by the given generator,
+// see https://go.dev/s/generatedcode.
+func (f *File) GeneratedNotice(generator string) {
+	f.notice = fmt.Sprintf("// Code generated by %s. DO NOT EDIT.", generator)
+}
+
+// PackageDoc sets the documentation of the package.
+func (f *File) PackageDoc(doc string) { f.doc = doc }
+
+// Import imports the path and returns the name under which it can be referenced.
+// If the name is already used by a different path, the path is imported under an alias.
+func (f *File) Import(importPath string) string {
+	if name, ok := f.imports[importPath]; ok {
+		return name
+	}
+
+	name := importPathToAssumedName(importPath)
+	if _, taken := f.names[name]; taken || token.Lookup(name).IsKeyword() {
+		name = f.alias(importPath, name)
+	}
+
+	f.imports[importPath] = name
+	f.names[name] = importPath
+
+	return name
+}
+
+// alias returns an unused name for the import path,
+// first by prefixing the name with the parent element of the path, unless it is a domain, and then by numbering it.
+func (f *File) alias(importPath, name string) string {
+	if parent := path.Base(path.Dir(importPath)); !strings.ContainsAny(parent, "./") {
+		alias := strings.ToLower(strings.Map(func(r rune) rune {
+			if notIdentifier(r) {
+				return -1
+			}
+
+			return r
+		}, parent)) + name
+
+		if _, taken := f.names[alias]; !taken {
+			return alias
+		}
+	}
+
+	for i := 2; ; i++ {
+		alias := name + strconv.Itoa(i)
+		if _, taken := f.names[alias]; !taken {
+			return alias
+		}
+	}
+}
+
+// Qual imports the path and returns the qualified identifier, e.g. "errors.Wrap".
+func (f *File) Qual(importPath, name string) string {
+	return f.Import(importPath) + "." + name
+}
+
+// Printf writes code to the body of the file.
+func (f *File) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(&f.body, format, args...)
+}
+
+// Comment writes a line comment for each line of the text.
+func (f *File) Comment(text string) {
+	f.body.WriteString(lineComments(text))
+}
+
+// Const declares a constant. The type is optional.
+func (f *File) Const(doc, name, typ, value string) {
+	f.decl(doc, fmt.Sprintf("const %s %s = %s", name, typ, value))
+}
+
+// Var declares a variable. Either the type or the value may be empty.
+func (f *File) Var(doc, name, typ, value string) {
+	decl := fmt.Sprintf("var %s %s", name, typ)
+	if value != "" {
+		decl += " = " + value
+	}
+
+	f.decl(doc, decl)
+}
+
+// Type declares a type with the given definition, e.g. "struct{ Foo string }".
+func (f *File) Type(doc, name, definition string) {
+	f.decl(doc, fmt.Sprintf("type %s %s", name, definition))
+}
+
+// Func declares a function or method with the given signature, e.g. "(f *Foo) Bar() error".
+// The body is indented by the writer.
+func (f *File) Func(doc, signature string, body func(w writers.Writer)) {
+	f.decl(doc, "func "+signature+" {\n")
+
+	if body != nil {
+		w := writers.NewIndentWriter(&f.body, 1)
+		body(w)
+		f.body.WriteByte('\n')
+	}
+
+	f.body.WriteString("}\n")
+}
+
+// decl writes a documented declaration, separated from the previous one by an empty line.
+func (f *File) decl(doc, decl string) {
+	if f.body.Len() > 0 {
+		f.body.WriteByte('\n')
+	}
+
+	f.Comment(doc)
+	f.body.WriteString(decl)
+
+	if !strings.HasSuffix(decl, "\n") {
+		f.body.WriteByte('\n')
+	}
+}
+
+// lineComments returns the text as line comments.
+func lineComments(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	b := &strings.Builder{}
+	w := writers.NewLinePrefixWriter(b, []byte("// "))
+
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			// Keep empty lines in the comment.
+			b.WriteString("//\n")
+			continue
+		}
+
+		_, _ = w.WriteString(line + "\n")
+	}
+
+	return b.String()
+}
+
+// Bytes returns the formatted source code.
+func (f *File) Bytes(opts ...Option) ([]byte, error) {
+	b := &bytes.Buffer{}
+
+	if f.notice != "" {
+		b.WriteString(f.notice + "\n\n")
+	}
+
+	b.WriteString(lineComments(f.doc))
+	b.WriteString("package " + f.pkg + "\n")
+
+	if len(f.imports) > 0 {
+		paths := make([]string, 0, len(f.imports))
+		for p := range f.imports {
+			paths = append(paths, p)
+		}
+
+		sort.Strings(paths)
+
+		b.WriteString("\nimport (\n")
+
+		for _, p := range paths {
+			if name := f.imports[p]; name != importPathToAssumedName(p) {
+				b.WriteString(name + " ")
+			}
+
+			b.WriteString(strconv.Quote(p) + "\n")
+		}
+
+		b.WriteString(")\n")
+	}
+
+	if f.body.Len() > 0 {
+		b.WriteByte('\n')
+		b.Write(f.body.Bytes())
+	}
+
+	return Format("", b.Bytes(), opts...)
+}
+
+// WriteFile writes the formatted source code to the file.
+func (f *File) WriteFile(filename string, opts ...Option) error {
+	src, err := f.Bytes(opts...)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filename, src, 0o644) //nolint:gosec,gomnd // normal permissions for source files
+}
@@ -0,0 +1,73 @@
+package golang_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/faetools/format/writers"
+	"github.com/stretchr/testify/assert"
+)
+
+const builtFile = `// Code generated by foo-gen. DO NOT EDIT.
+
+// Package foo does foo things.
+package foo
+
+import (
+	"errors"
+
+	pkgerrors "github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the version.
+const Version = "1.0"
+
+var ErrFoo = errors.New("foo")
+
+// Config is the config.
+//
+// It is read from yaml.
+type Config struct {
+	Name string
+}
+
+// Parse parses the config.
+func Parse(src []byte) (*Config, error) {
+	c := &Config{}
+	if err := yaml.Unmarshal(src, c); err != nil {
+		return nil, pkgerrors.Wrap(err, "parsing")
+	}
+
+	return c, nil
+}
+`
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+
+	f := golang.NewFile("foo")
+	f.GeneratedNotice("foo-gen")
+	f.PackageDoc("Package foo does foo things.")
+
+	f.Const("Version is the version.", "Version", "", `"1.0"`)
+	f.Var("", "ErrFoo", "", f.Qual("errors", "New")+`("foo")`)
+	f.Type("Config is the config.\n\nIt is read from yaml.", "Config", "struct {\nName string\n}")
+	f.Func("Parse parses the config.", "Parse(src []byte) (*Config, error)", func(w writers.Writer) {
+		fmt.Fprintf(w, "c := &Config{}\nif err := %s(src, c); err != nil {\n", f.Qual("gopkg.in/yaml.v3", "Unmarshal"))
+		fmt.Fprintf(w, "return nil, %s(err, %q)\n}\n\nreturn c, nil", f.Qual("github.com/pkg/errors", "Wrap"), "parsing")
+	})
+
+	out, err := f.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, builtFile, string(out))
+
+	assert.Equal(t, "errors2", f.Import("example.com/errors"))
+	assert.Equal(t, "pkgerrors", f.Import("github.com/pkg/errors"))
+
+	f.Printf("func Broken( {")
+
+	_, err = f.Bytes()
+	assert.Error(t, err)
+}
@@ -0,0 +1,347 @@
+package golang
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A Formatter formats go code like Format and shares what it learns about imports between files.
+// It is safe for concurrent use by multiple goroutines.
+//
+// Resolving imports with goimports is expensive since it scans GOROOT and the module cache for every file
+// that references packages it does not import. The Formatter therefore caches how package names were resolved:
+// missing imports are first added from the files of the same package directory formatted before or, failing that,
+// from any other file formatted before, if the imported package exports everything the file uses from it.
+// The exports of each package are loaded once per module. goimports then only has to scan for the imports that
+// are still missing.
+type Formatter struct {
+	opts  []Option
+	cache *importCache
+}
+
+// NewFormatter returns a formatter that formats go code with the given options.
+func NewFormatter(opts ...Option) *Formatter {
+	return &Formatter{opts: opts, cache: newImportCache()}
+}
+
+// Format formats golang code, see Format.
+func (f *Formatter) Format(filepath string, src []byte) ([]byte, error) {
+	opts := make([]Option, 0, len(f.opts)+1)
+	opts = append(opts, f.opts...)
+
+	return Format(filepath, src, append(opts, func(o *options) { o.cache = f.cache })...)
+}
+
+// FormatFiles formats the files concurrently and writes them if they changed.
+// The first error in the order of the files is returned.
+func (f *Formatter) FormatFiles(filepaths ...string) error {
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(filepaths))
+		sem  = make(chan struct{}, runtime.GOMAXPROCS(0))
+	)
+
+	for i, filepath := range filepaths {
+		wg.Add(1)
+
+		sem <- struct{}{}
+
+		go func(i int, filepath string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			errs[i] = errors.Wrapf(f.formatFile(filepath), "formatting %s", filepath)
+		}(i, filepath)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *Formatter) formatFile(filepath string) error {
+	src, err := os.ReadFile(filepath)
+	if err != nil {
+		return err
+	}
+
+	res, err := f.Format(filepath, src)
+	if err != nil || string(res) == string(src) {
+		return err
+	}
+
+	info, err := os.Stat(filepath)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath, res, info.Mode().Perm())
+}
+
+// importCache remembers how package names were resolved and what the imported packages export.
+type importCache struct {
+	mu      sync.Mutex
+	dirs    map[string]map[string]string // The import paths of package names in each directory, empty if ambiguous.
+	paths   map[string]map[string]bool   // All import paths of package names.
+	modules map[string]string            // The module root of each directory.
+	exports map[string]map[string]bool   // The exported names of packages by module root and import path.
+}
+
+func newImportCache() *importCache {
+	return &importCache{
+		dirs:    map[string]map[string]string{},
+		paths:   map[string]map[string]bool{},
+		modules: map[string]string{},
+		exports: map[string]map[string]bool{},
+	}
+}
+
+// addImports adds the missing imports that other files use under the same name,
+// if the imported package exports all names the file selects from it.
+// Imports of the same package directory are preferred over those of other directories.
+// The result is still processed by goimports, which removes anything that doesn't fit.
+func (c *importCache) addImports(filename string, src []byte) ([]byte, error) {
+	dir, err := packageDir(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := missingRefs(f)
+	if len(refs) == 0 {
+		return src, nil
+	}
+
+	// Anything declared by the package is not missing.
+	for _, sibling := range parseSiblings(os.DirFS(dir), fset, filepath.Base(filename), f.Name.Name) {
+		for _, name := range topLevelNames(sibling) {
+			delete(refs, name)
+		}
+	}
+
+	added := false
+
+	for name, sels := range selectedNames(f, refs) {
+		p := c.resolve(dir, name, sels)
+		if p == "" {
+			continue
+		}
+
+		addImport(fset, f, name, p)
+
+		added = true
+	}
+
+	res, _, err := printAddedImports(fset, f, src, added, false)
+
+	return res, err
+}
+
+// learn remembers the imports of the file.
+func (c *importCache) learn(filename string, src []byte) {
+	dir, err := packageDir(filename)
+	if err != nil {
+		return
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	paths := c.dirs[dir]
+	if paths == nil {
+		paths = map[string]string{}
+		c.dirs[dir] = paths
+	}
+
+	for _, s := range f.Imports {
+		name, p := importName(s), importPath(s)
+		if name == "_" || name == "." {
+			continue
+		}
+
+		switch known, ok := paths[name]; {
+		case !ok:
+			paths[name] = p
+		case known != p:
+			paths[name] = ""
+		}
+
+		if c.paths[name] == nil {
+			c.paths[name] = map[string]bool{}
+		}
+
+		c.paths[name][p] = true
+	}
+}
+
+// resolve returns the import path of the package name in dir that exports all names,
+// or an empty string if there is none or more than one package in other directories could be meant.
+func (c *importCache) resolve(dir, name string, names []string) string {
+	c.mu.Lock()
+	p := c.dirs[dir][name]
+
+	candidates := make([]string, 0, len(c.paths[name]))
+	for p := range c.paths[name] {
+		candidates = append(candidates, p)
+	}
+	c.mu.Unlock()
+
+	if p != "" {
+		if c.exportsAll(dir, p, names) {
+			return p
+		}
+
+		return ""
+	}
+
+	found := ""
+
+	for _, p := range candidates {
+		if !c.exportsAll(dir, p, names) {
+			continue
+		}
+
+		if found != "" {
+			return ""
+		}
+
+		found = p
+	}
+
+	return found
+}
+
+// exportsAll reports whether the package with the import path, as resolved from dir, exports all names.
+func (c *importCache) exportsAll(dir, importPath string, names []string) bool {
+	key := c.moduleRoot(dir) + "\x00" + importPath
+
+	c.mu.Lock()
+	exports, ok := c.exports[key]
+	c.mu.Unlock()
+
+	if !ok {
+		// Packages that can't be loaded export nothing, leaving the import to goimports.
+		exports, _ = loadExports(dir, importPath)
+
+		c.mu.Lock()
+		c.exports[key] = exports
+		c.mu.Unlock()
+	}
+
+	for _, name := range names {
+		if !exports[name] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// moduleRoot returns the directory of the go.mod file dir belongs to or dir itself if there is none.
+// Import paths resolve to the same packages within a module.
+func (c *importCache) moduleRoot(dir string) string {
+	c.mu.Lock()
+	root, ok := c.modules[dir]
+	c.mu.Unlock()
+
+	if ok {
+		return root
+	}
+
+	root = dir
+
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			root = d
+			break
+		}
+
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+
+	c.mu.Lock()
+	c.modules[dir] = root
+	c.mu.Unlock()
+
+	return root
+}
+
+// loadExports returns the exported top-level names of the package with the import path, as resolved from dir.
+func loadExports(dir, importPath string) (map[string]bool, error) {
+	pkg, err := build.Import(importPath, dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	exports := map[string]bool{}
+
+	for _, name := range append(pkg.GoFiles, pkg.CgoFiles...) {
+		f, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range topLevelNames(f) {
+			if ast.IsExported(name) {
+				exports[name] = true
+			}
+		}
+	}
+
+	return exports, nil
+}
+
+// selectedNames returns the names selected from each of the package names.
+func selectedNames(f *ast.File, pkgs map[string]bool) map[string][]string {
+	sels := map[string][]string{}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil && pkgs[x.Name] {
+				sels[x.Name] = append(sels[x.Name], sel.Sel.Name)
+			}
+		}
+
+		return true
+	})
+
+	return sels
+}
+
+// packageDir returns the absolute directory of the file.
+func packageDir(filename string) (string, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Dir(abs), nil
+}
@@ -0,0 +1,129 @@
+package golang_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatter(t *testing.T) {
+	t.Parallel()
+
+	f := golang.NewFormatter()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			out, err := f.Format("", []byte(before))
+			assert.NoError(t, err)
+			assert.Equal(t, after, string(out))
+		}()
+	}
+
+	wg.Wait()
+
+	// The import of the first file is used to resolve the missing import of the second.
+	out, err := f.Format("a.go", []byte("package foo\n\nimport \"github.com/pkg/errors\"\n\nvar Err = errors.New(\"a\")\n"))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"github.com/pkg/errors"`)
+
+	out, err = f.Format("b.go", []byte("package foo\n\nfunc b(err error) error { return errors.Wrap(err, \"b\") }\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package foo\n\nimport \"github.com/pkg/errors\"\n\n"+
+		"func b(err error) error { return errors.Wrap(err, \"b\") }\n", string(out))
+}
+
+func TestFormatter_ImportExports(t *testing.T) {
+	t.Parallel()
+
+	f := golang.NewFormatter()
+
+	// The standard library's errors does not export Wrap, so it must not be used for the second file.
+	_, err := f.Format("a.go", []byte("package foo\n\nimport \"errors\"\n\nvar Err = errors.New(\"a\")\n"))
+	require.NoError(t, err)
+
+	out, err := f.Format("b.go", []byte("package foo\n\nfunc b(err error) error { return errors.Wrap(err, \"b\") }\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "package foo\n\nimport \"github.com/pkg/errors\"\n\n"+
+		"func b(err error) error { return errors.Wrap(err, \"b\") }\n", string(out))
+}
+
+func TestFormatter_FormatFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	var paths []string
+
+	for i := 0; i < 10; i++ {
+		p := filepath.Join(dir, fmt.Sprintf("file%d.go", i))
+		require.NoError(t, os.WriteFile(p, []byte(before), 0o600))
+
+		paths = append(paths, p)
+	}
+
+	require.NoError(t, golang.NewFormatter().FormatFiles(paths...))
+
+	for _, p := range paths {
+		out, err := os.ReadFile(p)
+		require.NoError(t, err)
+		assert.Equal(t, after, string(out))
+	}
+
+	err := golang.NewFormatter().FormatFiles(filepath.Join(dir, "missing.go"))
+	assert.EqualError(t, err, fmt.Sprintf("formatting %[1]s: open %[1]s: no such file or directory",
+		filepath.Join(dir, "missing.go")))
+}
+
+func TestFormatter_OtherDirectories(t *testing.T) {
+	t.Parallel()
+
+	f := golang.NewFormatter()
+
+	// goimports can't resolve the alias, the import of a file in another directory is used instead.
+	_, err := f.Format("../sql/a.go", []byte("package foo\n\nimport fyaml \"github.com/faetools/format/yaml\"\n\n"+
+		"var _ = fyaml.Format\n"))
+	require.NoError(t, err)
+
+	out, err := f.Format("../markdown/b.go", []byte("package foo\n\nvar _, _ = fyaml.Marshal(nil)\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "package foo\n\nimport fyaml \"github.com/faetools/format/yaml\"\n\n"+
+		"var _, _ = fyaml.Marshal(nil)\n", string(out))
+
+	// The package must export everything that is used.
+	out, err = f.Format("../markdown/c.go", []byte("package foo\n\nvar _ = fyaml.Missing\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "package foo\n\nvar _ = fyaml.Missing\n", string(out))
+}
+
+// missingImport needs goimports to scan for a package unless the import is cached.
+const missingImport = "package foo\n\nfunc b(err error) error { return errors.Wrap(err, \"b\") }\n"
+
+func BenchmarkFormatter(b *testing.B) {
+	f := golang.NewFormatter()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := f.Format("foo.go", []byte(missingImport)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, err := golang.Format("foo.go", []byte(missingImport)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
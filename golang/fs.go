@@ -0,0 +1,299 @@
+package golang
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/imports"
+)
+
+// FormatFS formats the golang file at the given path within fsys.
+// See WithFS for how the file system is used.
+func FormatFS(fsys fs.FS, filepath string, opts ...Option) ([]byte, error) {
+	return Format(filepath, nil, append(opts[:len(opts):len(opts)], WithFS(fsys))...)
+}
+
+// WithFS makes Format use fsys instead of the disk: the source is read from fsys if it is not given,
+// and imports are resolved with the help of the other files of the same package in fsys.
+// This way, code can be formatted before it is written, e.g. by using a fstest.MapFS as an in-memory overlay.
+//
+// Only if a file references packages that are neither imported by the file nor its siblings,
+// will the module and GOROOT be searched for them.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+func (o *options) readSource(filepath string, src []byte) ([]byte, error) {
+	if src != nil || o.fsys == nil {
+		return src, nil
+	}
+
+	src, err := fs.ReadFile(o.fsys, filepath)
+
+	return src, errors.Wrapf(err, "reading %s", filepath)
+}
+
+// fixImports adds missing and removes unused imports.
+func (o *options) fixImports(filepath string, src []byte) ([]byte, error) {
+	opt := &imports.Options{Comments: true, TabIndent: true, TabWidth: 8} //nolint:gomnd // default of imports
+
+	if o.fsys != nil {
+		// Parsing errors are reported when running 'imports'.
+		if res, complete, err := addSiblingImports(o.fsys, filepath, src); err == nil {
+			src, opt.FormatOnly = res, complete
+		}
+	}
+
+	if o.cache != nil && !opt.FormatOnly && src != nil {
+		// Parsing errors are reported when running 'imports'.
+		if res, err := o.cache.addImports(filepath, src); err == nil {
+			src = res
+		}
+	}
+
+	res, err := imports.Process(filepath, src, opt)
+	if err != nil {
+		return nil, errors.Wrap(err, "running 'imports'")
+	}
+
+	if o.cache != nil {
+		o.cache.learn(filepath, res)
+	}
+
+	return res, nil
+}
+
+// addSiblingImports adds all imports that are missing in src but are imported by other files of the same package.
+// It also reports if the imports are complete, i.e. no references are missing and no imports are unused.
+func addSiblingImports(fsys fs.FS, filename string, src []byte) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+
+	refs := missingRefs(f)
+	if len(refs) == 0 {
+		return src, !hasUnusedImports(f), nil
+	}
+
+	added := false
+
+	for _, sibling := range parseSiblings(fsys, fset, filename, f.Name.Name) {
+		// Anything declared by the package is not missing.
+		for _, name := range topLevelNames(sibling) {
+			delete(refs, name)
+		}
+
+		for _, s := range sibling.Imports {
+			name, p := importName(s), importPath(s)
+			if !refs[name] {
+				continue
+			}
+
+			addImport(fset, f, name, p)
+			delete(refs, name)
+
+			added = true
+		}
+	}
+
+	return printAddedImports(fset, f, src, added, len(refs) == 0)
+}
+
+// addImport imports the path under the given name, adding the name only if it differs from the assumed name.
+func addImport(fset *token.FileSet, f *ast.File, name, importPath string) {
+	if name == importPathToAssumedName(importPath) {
+		astutil.AddImport(fset, f, importPath)
+	} else {
+		astutil.AddNamedImport(fset, f, name, importPath)
+	}
+}
+
+// printAddedImports prints the file if imports were added and reports whether the imports are complete.
+func printAddedImports(fset *token.FileSet, f *ast.File, src []byte, added, resolved bool) ([]byte, bool, error) {
+	complete := resolved && !hasUnusedImports(f)
+	if !added {
+		return src, complete, nil
+	}
+
+	b := &bytes.Buffer{}
+	if err := format.Node(b, fset, f); err != nil {
+		return nil, false, err
+	}
+
+	return b.Bytes(), complete, nil
+}
+
+// parseSiblings parses all other go files in the same directory that belong to the same package.
+// Files that can't be read or parsed are ignored.
+func parseSiblings(fsys fs.FS, fset *token.FileSet, filename, pkg string) []*ast.File {
+	dir := path.Dir(filename)
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil
+	}
+
+	considerTests := strings.HasSuffix(filename, "_test.go")
+
+	var files []*ast.File
+
+	for _, e := range entries {
+		name := e.Name()
+
+		switch {
+		case e.IsDir(), name == path.Base(filename), !strings.HasSuffix(name, ".go"),
+			!considerTests && strings.HasSuffix(name, "_test.go"):
+			continue
+		}
+
+		p := path.Join(dir, name)
+
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, p, src, parser.SkipObjectResolution)
+		if err != nil || f.Name.Name != pkg {
+			continue
+		}
+
+		files = append(files, f)
+	}
+
+	return files
+}
+
+// missingRefs returns the names of all packages that are referenced but neither declared nor imported.
+func missingRefs(f *ast.File) map[string]bool {
+	imported := map[string]bool{}
+	for _, s := range f.Imports {
+		imported[importName(s)] = true
+	}
+
+	refs := map[string]bool{}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil && !imported[x.Name] {
+			refs[x.Name] = true
+		}
+
+		return true
+	})
+
+	return refs
+}
+
+// hasUnusedImports reports whether any imported package is not referenced.
+func hasUnusedImports(f *ast.File) bool {
+	used := map[string]bool{}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil {
+				used[x.Name] = true
+			}
+		}
+
+		return true
+	})
+
+	for _, s := range f.Imports {
+		switch name := importName(s); name {
+		case "_", ".":
+		default:
+			if !used[name] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func topLevelNames(f *ast.File) (names []string) {
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				names = append(names, d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.TypeSpec:
+					names = append(names, s.Name.Name)
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						names = append(names, n.Name)
+					}
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+func importPath(s *ast.ImportSpec) string {
+	p, _ := strconv.Unquote(s.Path.Value)
+	return p
+}
+
+// importName returns the name under which the import is used.
+func importName(s *ast.ImportSpec) string {
+	if s.Name != nil {
+		return s.Name.Name
+	}
+
+	return importPathToAssumedName(importPath(s))
+}
+
+// importPathToAssumedName returns the assumed package name of an import path.
+// Taken from golang.org/x/tools/internal/imports/fix.go.
+func importPathToAssumedName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			if dir := path.Dir(importPath); dir != "." {
+				base = path.Base(dir)
+			}
+		}
+	}
+
+	base = strings.TrimPrefix(base, "go-")
+
+	if i := strings.IndexFunc(base, notIdentifier); i >= 0 {
+		base = base[:i]
+	}
+
+	return base
+}
+
+func notIdentifier(ch rune) bool {
+	return !('a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' ||
+		'0' <= ch && ch <= '9' ||
+		ch == '_' ||
+		ch >= utf8.RuneSelf && (unicode.IsLetter(ch) || unicode.IsDigit(ch)))
+}
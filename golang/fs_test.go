@@ -0,0 +1,65 @@
+package golang_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatFS(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"pkg/config.go": {Data: []byte(`package pkg
+
+import (
+	cfg "github.com/faetools/does-not-exist/config"
+	"github.com/faetools/format/yaml"
+)
+
+var Default = cfg.New()
+
+func Encode(v interface{}) ([]byte, error) { return yaml.Encode(v) }
+`)},
+		"pkg/generated.go": {Data: []byte(`package pkg
+func Load(src []byte) (*cfg.Config, error) {
+	c := Default
+	return c, yaml.Unmarshal(src, c)
+}
+`)},
+	}
+
+	out, err := golang.FormatFS(fsys, "pkg/generated.go")
+	assert.NoError(t, err)
+	assert.Equal(t, `package pkg
+
+import (
+	cfg "github.com/faetools/does-not-exist/config"
+	"github.com/faetools/format/yaml"
+)
+
+func Load(src []byte) (*cfg.Config, error) {
+	c := Default
+	return c, yaml.Unmarshal(src, c)
+}
+`, string(out))
+}
+
+func TestFormatFS_Failure(t *testing.T) {
+	t.Parallel()
+
+	_, err := golang.FormatFS(fstest.MapFS{}, "foo.go")
+	assert.EqualError(t, err, "reading foo.go: open foo.go: file does not exist")
+}
+
+func TestFormat_WithFS(t *testing.T) {
+	t.Parallel()
+
+	// Standard library imports are still added.
+	out, err := golang.Format("foo/foo.go", []byte("package foo\nvar _ = fmt.Sprint"),
+		golang.WithFS(fstest.MapFS{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "package foo\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint\n", string(out))
+}
@@ -1,9 +1,10 @@
 package golang
 
 import (
+	"os"
+
 	"github.com/faetools/format/format"
 	"github.com/pkg/errors"
-	"golang.org/x/tools/imports"
 	gofumpt "mvdan.cc/gofumpt/format"
 )
 
@@ -14,11 +15,86 @@ var FormatOptions = gofumpt.Options{
 }
 
 // Format formats golang code.
-func Format(filepath string, src []byte) ([]byte, error) {
-	res, err := imports.Process(filepath, src, nil)
+func Format(filepath string, src []byte, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	o.filename = filepath
+
+	src, err := o.readSource(filepath, src)
 	if err != nil {
-		return nil, errors.Wrapf(err, "running 'imports'")
+		return nil, err
+	}
+
+	for _, s := range o.steps() {
+		if src, err = s.run(src); err != nil {
+			return nil, err
+		}
+	}
+
+	o.checkEmbeds(src)
+
+	return src, nil
+}
+
+// A step is a part of formatting go code.
+type step struct {
+	name Step
+	run  func(src []byte) ([]byte, error)
+}
+
+// steps returns the steps of formatting go code in the order they are run.
+func (o *options) steps() []step {
+	all := []step{
+		{StepRewrite, func(src []byte) ([]byte, error) {
+			if len(o.rules) == 0 {
+				return src, nil
+			}
+
+			// Without a source, the file has to be read here as the rules cannot be applied later.
+			if src == nil {
+				var err error
+				if src, err = os.ReadFile(o.filename); err != nil {
+					return nil, errors.Wrap(err, "reading")
+				}
+			}
+
+			res, err := Rewrite(src, o.rules...)
+
+			return res, errors.Wrap(err, "rewriting")
+		}},
+		{StepDirectives, func(src []byte) ([]byte, error) {
+			if src == nil {
+				return nil, nil
+			}
+
+			return normalizeDirectives(src), nil
+		}},
+		{stepImportGroups, func(src []byte) ([]byte, error) {
+			// Group the imports beforehand so that sorting them doesn't move comments to different imports.
+			// Parsing errors are reported when running 'imports'.
+			if grouped, err := o.regroupImports(src); err == nil && src != nil {
+				return grouped, nil
+			}
+
+			return src, nil
+		}},
+		{StepImports, func(src []byte) ([]byte, error) { return o.fixImports(o.filename, src) }},
+		{StepGofmt, func(src []byte) ([]byte, error) { return Gofmt(src) }},
+		{StepGofumpt, func(src []byte) ([]byte, error) { return gofumpt.Source(src, o.gofumpt) }},
+		{StepLiterals, o.formatLiterals},
+		{StepDocComments, o.formatDocComments},
+		{StepLineWrapping, o.wrapLines},
+		{StepStructTags, o.formatTags},
+		// Place any imports that were added.
+		{stepImportGroups, o.regroupImports},
+	}
+
+	steps := make([]step, 0, len(all))
+
+	for _, s := range all {
+		if o.runs(s.name) {
+			steps = append(steps, s)
+		}
 	}
 
-	return gofumpt.Source(res, FormatOptions)
+	return steps
 }
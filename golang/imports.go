@@ -0,0 +1,206 @@
+package golang
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	groupStd = iota
+	groupThirdParty
+	groupLocal // The first local group, any further local groups follow.
+)
+
+type importSpec struct {
+	spec    *ast.ImportSpec
+	path    string
+	doc     []string // Comments above the import.
+	comment string   // Comment on the same line as the import.
+	group   int
+}
+
+// regroupImports sorts all imports into the groups defined by the options.
+func (o *options) regroupImports(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.ImportsOnly)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing imports")
+	}
+
+	b := &bytes.Buffer{}
+	last := 0
+
+	for _, decl := range f.Decls {
+		d, ok := decl.(*ast.GenDecl)
+		if !ok || d.Tok != token.IMPORT || !d.Lparen.IsValid() || isCgoImport(d) {
+			continue
+		}
+
+		start, end := fset.Position(d.Pos()).Offset, fset.Position(d.End()).Offset
+
+		b.Write(src[last:start])
+		o.writeImportDecl(b, fset, f, d)
+
+		last = end
+	}
+
+	if last == 0 {
+		return src, nil
+	}
+
+	b.Write(src[last:])
+
+	res, err := format.Source(b.Bytes())
+
+	return res, errors.Wrap(err, "formatting regrouped imports")
+}
+
+func (o *options) writeImportDecl(b *bytes.Buffer, fset *token.FileSet, f *ast.File, d *ast.GenDecl) {
+	specs := make([]*importSpec, len(d.Specs))
+	for i, s := range d.Specs {
+		s := s.(*ast.ImportSpec) //nolint:forcetypeassert // import declarations only contain import specs
+		path, _ := strconv.Unquote(s.Path.Value)
+
+		specs[i] = &importSpec{spec: s, path: path, group: o.importGroup(s, path)}
+	}
+
+	// Comments that come after the last import stay at the end.
+	trailing := attachComments(fset, f, d, specs)
+
+	sort.SliceStable(specs, func(i, j int) bool {
+		if specs[i].group != specs[j].group {
+			return specs[i].group < specs[j].group
+		}
+
+		return specs[i].path < specs[j].path
+	})
+
+	b.WriteString("import (\n")
+
+	for i, s := range specs {
+		if i > 0 && specs[i-1].group != s.group {
+			b.WriteByte('\n')
+		}
+
+		for _, c := range s.doc {
+			b.WriteString("\t" + c + "\n")
+		}
+
+		b.WriteByte('\t')
+
+		if s.spec.Name != nil {
+			b.WriteString(s.spec.Name.Name + " ")
+		}
+
+		b.WriteString(s.spec.Path.Value)
+
+		if s.comment != "" {
+			b.WriteString(" " + s.comment)
+		}
+
+		b.WriteByte('\n')
+	}
+
+	for _, c := range trailing {
+		b.WriteString("\t" + c + "\n")
+	}
+
+	b.WriteString(")")
+}
+
+// attachComments attaches all comments inside the import declaration to the imports they belong to
+// and returns those that are placed after the last import.
+func attachComments(fset *token.FileSet, f *ast.File, d *ast.GenDecl, specs []*importSpec) (trailing []string) {
+	line := func(p token.Pos) int { return fset.Position(p).Line }
+
+	next := 0
+
+	for _, cg := range f.Comments {
+		if cg.Pos() < d.Lparen || cg.End() > d.Rparen {
+			continue
+		}
+
+		for next < len(specs) && specs[next].spec.End() <= cg.Pos() {
+			next++
+		}
+
+		if next > 0 && line(specs[next-1].spec.End()) == line(cg.Pos()) {
+			specs[next-1].comment = commentText(cg)
+			continue
+		}
+
+		if next < len(specs) {
+			specs[next].doc = append(specs[next].doc, commentLines(cg)...)
+			continue
+		}
+
+		trailing = append(trailing, commentLines(cg)...)
+	}
+
+	return trailing
+}
+
+func commentLines(cg *ast.CommentGroup) []string {
+	lines := make([]string, len(cg.List))
+	for i, c := range cg.List {
+		lines[i] = c.Text
+	}
+
+	return lines
+}
+
+func commentText(cg *ast.CommentGroup) string {
+	return strings.Join(commentLines(cg), " ")
+}
+
+// importGroup returns the group the import belongs in.
+func (o *options) importGroup(s *ast.ImportSpec, path string) int {
+	if o.separateSideEffects && s.Name != nil && (s.Name.Name == "_" || s.Name.Name == ".") {
+		return groupLocal + len(o.localPrefixes)
+	}
+
+	// The longest matching prefix wins, so that more specific prefixes can be grouped separately.
+	group, longest := -1, 0
+
+	for i, prefix := range o.localPrefixes {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			group, longest = i, len(prefix)
+		}
+	}
+
+	switch {
+	case group >= 0:
+		return groupLocal + group
+	case isStdImport(path):
+		return groupStd
+	default:
+		return groupThirdParty
+	}
+}
+
+// isStdImport reports whether the path belongs to the standard library,
+// which is the case if the first path element does not contain a dot.
+func isStdImport(path string) bool {
+	first := strings.SplitN(path, "/", 2)[0] //nolint:gomnd // first element and rest
+
+	return !strings.Contains(first, ".")
+}
+
+func isCgoImport(d *ast.GenDecl) bool {
+	for _, s := range d.Specs {
+		if s, ok := s.(*ast.ImportSpec); ok && s.Path.Value == `"C"` {
+			return true
+		}
+	}
+
+	return false
+}
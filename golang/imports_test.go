@@ -0,0 +1,78 @@
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	misplacedImports = `package main
+
+import (
+	"github.com/faetools/kit/terminal"
+	"fmt"
+	"github.com/pkg/errors" // third party
+	_ "embed"
+
+	// the format library
+	"github.com/faetools/format/yaml"
+	"os"
+	. "github.com/faetools/format/golang"
+)
+
+var (
+	_ = fmt.Sprint
+	_ = os.Exit
+	_ = errors.New
+	_ = terminal.Println
+	_ = yaml.Format
+	_ = Format
+)
+`
+
+	regroupedImports = `package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors" // third party
+
+	"github.com/faetools/kit/terminal"
+
+	// the format library
+	"github.com/faetools/format/yaml"
+
+	_ "embed"
+	. "github.com/faetools/format/golang"
+)
+
+var (
+	_ = fmt.Sprint
+	_ = os.Exit
+	_ = errors.New
+	_ = terminal.Println
+	_ = yaml.Format
+	_ = Format
+)
+`
+)
+
+func TestFormat_ImportGroups(t *testing.T) {
+	t.Parallel()
+
+	out, err := golang.Format("", []byte(misplacedImports),
+		golang.WithLocalPrefixes("github.com/faetools", "github.com/faetools/format"),
+		golang.WithSideEffectImportsGroup)
+	assert.NoError(t, err)
+	assert.Equal(t, regroupedImports, string(out))
+
+	// Formatting again doesn't change anything.
+	out, err = golang.Format("", out,
+		golang.WithLocalPrefixes("github.com/faetools", "github.com/faetools/format"),
+		golang.WithSideEffectImportsGroup)
+	assert.NoError(t, err)
+	assert.Equal(t, regroupedImports, string(out))
+}
@@ -0,0 +1,329 @@
+package golang
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/faetools/format/sql"
+	"github.com/faetools/format/yaml"
+	"github.com/pkg/errors"
+	"github.com/tidwall/pretty"
+)
+
+const literalMarker = "//format:"
+
+// WithEmbeddedLiterals formats the contents of raw string literals that are marked with a comment like
+// //format:json, //format:yaml or //format:sql, either alone on the line above the literal or after it.
+// The indentation of the contents relative to the surrounding code is kept.
+// Literals that can't be formatted are reported as diagnostics, see WithDiagnostics.
+var WithEmbeddedLiterals Option = func(o *options) {
+	o.literals.enabled = true
+}
+
+// WithLiteralCallSites formats raw string literals of the given kind, e.g. "sql", that are passed
+// to any of the functions, e.g. "QueryContext" or "yaml.Unmarshal", even if they are not marked.
+// Names without a dot match method calls on any receiver. It implies WithEmbeddedLiterals.
+func WithLiteralCallSites(kind string, funcs ...string) Option {
+	return func(o *options) {
+		if o.literals.callSites == nil {
+			o.literals.callSites = map[string]string{}
+		}
+
+		for _, f := range funcs {
+			o.literals.callSites[f] = kind
+		}
+
+		o.literals.enabled = true
+	}
+}
+
+// WithLiteralFormatter sets the formatter for a kind of embedded literal, replacing any built-in formatter.
+// It implies WithEmbeddedLiterals.
+func WithLiteralFormatter(kind string, format func(src []byte) ([]byte, error)) Option {
+	return func(o *options) {
+		if o.literals.formatters == nil {
+			o.literals.formatters = map[string]func([]byte) ([]byte, error){}
+		}
+
+		o.literals.formatters[kind] = format
+		o.literals.enabled = true
+	}
+}
+
+type literalOptions struct {
+	enabled    bool
+	callSites  map[string]string
+	formatters map[string]func([]byte) ([]byte, error)
+}
+
+// literalFormatters are the built-in formatters of embedded literals.
+var literalFormatters = map[string]func([]byte) ([]byte, error){
+	"json": func(src []byte) ([]byte, error) {
+		if !json.Valid(src) {
+			return nil, errors.New("invalid json")
+		}
+
+		return pretty.PrettyOptions(src, &pretty.Options{Indent: "  "}), nil
+	},
+	"yaml": func(src []byte) ([]byte, error) { return yaml.Format(src) },
+	"sql":  sql.Format,
+}
+
+func (o *options) literalFormatter(kind string) func([]byte) ([]byte, error) {
+	if f, ok := o.literals.formatters[kind]; ok {
+		return f
+	}
+
+	return literalFormatters[kind]
+}
+
+// formatLiterals formats all embedded literals.
+func (o *options) formatLiterals(src []byte) ([]byte, error) {
+	if !o.literals.enabled {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, o.filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing")
+	}
+
+	line := func(p token.Pos) int { return fset.Position(p).Line }
+
+	// The kinds of literals as marked by comments on their lines.
+	markers := map[int]marker{}
+
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			if !strings.HasPrefix(c.Text, literalMarker) {
+				continue
+			}
+
+			start := fset.Position(c.Pos()).Offset
+			lineStart := bytes.LastIndexByte(src[:start], '\n') + 1
+
+			markers[line(c.Pos())] = marker{
+				kind:  strings.TrimSpace(strings.TrimPrefix(c.Text, literalMarker)),
+				alone: len(bytes.TrimSpace(src[lineStart:start])) == 0,
+			}
+		}
+	}
+
+	b := &bytes.Buffer{}
+	last := 0
+	addedLines := 0 // The number of lines added by formatting the literals so far.
+
+	// position returns the position of the literal in the formatted code.
+	position := func(lit *ast.BasicLit) token.Position {
+		pos := fset.Position(lit.Pos())
+		pos.Line += addedLines
+
+		return pos
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		lit, kind := o.embeddedLiteral(n, markers, line)
+		if lit == nil {
+			return true
+		}
+
+		format := o.literalFormatter(kind)
+		if format == nil {
+			o.reportf(position(lit), "unknown kind of literal %q", kind)
+			return true
+		}
+
+		start := fset.Position(lit.Pos()).Offset
+		lineStart := bytes.LastIndexByte(src[:start], '\n') + 1
+		codeIndent := src[lineStart : lineStart+len(src[lineStart:start])-len(bytes.TrimLeft(src[lineStart:start], " \t"))]
+
+		formatted, err := formatLiteral(lit.Value, string(codeIndent), format)
+		if err != nil {
+			o.reportf(position(lit), "cannot format %s literal: %v", kind, err)
+			return true
+		}
+
+		addedLines += strings.Count(formatted, "\n") - strings.Count(lit.Value, "\n")
+
+		b.Write(src[last:start])
+		b.WriteString(formatted)
+
+		last = start + len(lit.Value)
+
+		return true
+	})
+
+	if last == 0 {
+		return src, nil
+	}
+
+	b.Write(src[last:])
+
+	return b.Bytes(), nil
+}
+
+// A marker is a comment marking the kind of a literal.
+type marker struct {
+	kind  string
+	alone bool // Whether the comment is alone on its line.
+}
+
+// embeddedLiteral returns the raw string literal of the node and its kind if it should be formatted.
+// Literals are either marked by a comment alone on the line before them or trailing their last line,
+// or passed to one of the configured call sites.
+func (o *options) embeddedLiteral(n ast.Node, markers map[int]marker, line func(token.Pos) int) (*ast.BasicLit, string) {
+	markedKind := func(lit *ast.BasicLit) (string, bool) {
+		if m, ok := markers[line(lit.Pos())-1]; ok && m.alone {
+			return m.kind, true
+		}
+
+		m, ok := markers[line(lit.End())]
+
+		return m.kind, ok && !m.alone
+	}
+
+	if lit, ok := n.(*ast.BasicLit); ok && isRawString(lit) {
+		if kind, ok := markedKind(lit); ok {
+			return lit, kind
+		}
+
+		return nil, ""
+	}
+
+	call, ok := n.(*ast.CallExpr)
+	if !ok || len(o.literals.callSites) == 0 {
+		return nil, ""
+	}
+
+	kind, ok := o.literals.callSites[calledName(call.Fun)]
+	if !ok {
+		if sel, isSel := call.Fun.(*ast.SelectorExpr); isSel {
+			kind, ok = o.literals.callSites[sel.Sel.Name]
+		}
+	}
+
+	if !ok {
+		return nil, ""
+	}
+
+	for _, arg := range call.Args {
+		// Also consider conversions like []byte(`...`).
+		if conv, isConv := arg.(*ast.CallExpr); isConv && len(conv.Args) == 1 {
+			arg = conv.Args[0]
+		}
+
+		if lit, isLit := arg.(*ast.BasicLit); isLit && isRawString(lit) {
+			if _, marked := markedKind(lit); !marked {
+				return lit, kind
+			}
+		}
+	}
+
+	return nil, ""
+}
+
+func isRawString(lit *ast.BasicLit) bool {
+	return lit.Kind == token.STRING && strings.HasPrefix(lit.Value, "`")
+}
+
+// calledName returns the name of the called function, e.g. "yaml.Unmarshal".
+func calledName(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		if x := calledName(f.X); x != "" {
+			return x + "." + f.Sel.Name
+		}
+	}
+
+	return ""
+}
+
+// formatLiteral formats the contents of a raw string literal.
+// The contents are formatted without their common indentation, which is added again afterwards.
+// Contents that were on a single line are indented like the code if they span several lines after formatting.
+// A leading line break and the indentation before the closing backquote are kept.
+func formatLiteral(lit, codeIndent string, format func([]byte) ([]byte, error)) (string, error) {
+	content := lit[1 : len(lit)-1]
+
+	lead := ""
+	if strings.HasPrefix(content, "\n") {
+		lead, content = "\n", content[1:]
+	}
+
+	tail := ""
+	if i := strings.LastIndexByte(content, '\n'); i >= 0 && strings.TrimLeft(content[i:], " \t\n") == "" {
+		tail, content = content[i:], content[:i]
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return lit, nil
+	}
+
+	lines := strings.Split(content, "\n")
+
+	// Without a leading line break, the first line starts right after the backquote and has no indentation.
+	first := 0
+	if lead == "" {
+		first = 1
+	}
+
+	indent := commonIndent(lines[first:])
+	if len(lines) == first {
+		indent = codeIndent
+	}
+
+	for i, l := range lines {
+		lines[i] = strings.TrimPrefix(l, indent)
+	}
+
+	res, err := format([]byte(strings.Join(lines, "\n") + "\n"))
+	if err != nil {
+		return "", err
+	}
+
+	if bytes.ContainsRune(res, '`') {
+		return "", errors.New("the formatted contents contain a backquote")
+	}
+
+	lines = strings.Split(strings.TrimRight(string(res), "\n"), "\n")
+	for i := first; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+
+	return "`" + lead + strings.Join(lines, "\n") + tail + "`", nil
+}
+
+// commonIndent returns the indentation that all non-empty lines share.
+func commonIndent(lines []string) string {
+	indent := ""
+	first := true
+
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+
+		i := l[:len(l)-len(strings.TrimLeft(l, " \t"))]
+
+		switch {
+		case first:
+			indent, first = i, false
+		default:
+			for !strings.HasPrefix(i, indent) {
+				indent = indent[:len(indent)-1]
+			}
+		}
+	}
+
+	return indent
+}
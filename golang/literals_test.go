@@ -0,0 +1,96 @@
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	unformattedLiterals = "package foo\n\n" +
+		"import \"database/sql\"\n\n" +
+		"func f(db *sql.DB) {\n" +
+		"\t//format:json\n" +
+		"\tfixture := `\n" +
+		"\t\t{\"name\":\"foo\",\"tags\":[\"a\",\"b\"]}\n" +
+		"\t`\n\n" +
+		"\tconfig := `{\"a\":1}` //format:json\n\n" +
+		"\t//format:yaml\n" +
+		"\tmanifest := `\n" +
+		"\t\tkind:   Deployment\n" +
+		"\t\tspec:\n" +
+		"\t\t    replicas: 2\n" +
+		"\t`\n\n" +
+		"\tdb.Query(`select * from users where id = $1`, 1)\n\n" +
+		"\t//format:json\n" +
+		"\tbroken := `{`\n\n" +
+		"\t_, _, _, _ = fixture, config, manifest, broken\n" +
+		"}\n"
+
+	formattedLiterals = "package foo\n\n" +
+		"import \"database/sql\"\n\n" +
+		"func f(db *sql.DB) {\n" +
+		"\t//format:json\n" +
+		"\tfixture := `\n" +
+		"\t\t{\n" +
+		"\t\t  \"name\": \"foo\",\n" +
+		"\t\t  \"tags\": [\n" +
+		"\t\t    \"a\",\n" +
+		"\t\t    \"b\"\n" +
+		"\t\t  ]\n" +
+		"\t\t}\n" +
+		"\t`\n\n" +
+		"\tconfig := `{\n" +
+		"\t  \"a\": 1\n" +
+		"\t}` //format:json\n\n" +
+		"\t//format:yaml\n" +
+		"\tmanifest := `\n" +
+		"\t\tkind: Deployment\n" +
+		"\t\tspec:\n" +
+		"\t\t  replicas: 2\n" +
+		"\t`\n\n" +
+		"\tdb.Query(`SELECT *\n" +
+		"\tFROM users\n" +
+		"\tWHERE id = $1`, 1)\n\n" +
+		"\t//format:json\n" +
+		"\tbroken := `{`\n\n" +
+		"\t_, _, _, _ = fixture, config, manifest, broken\n" +
+		"}\n"
+)
+
+func TestFormat_EmbeddedLiterals(t *testing.T) {
+	t.Parallel()
+
+	var diagnostics []string
+
+	out, err := golang.Format("foo.go", []byte(unformattedLiterals),
+		golang.WithLiteralCallSites("sql", "Query"),
+		golang.WithDiagnostics(func(d golang.Diagnostic) {
+			diagnostics = append(diagnostics, d.String())
+		}))
+	assert.NoError(t, err)
+	assert.Equal(t, formattedLiterals, string(out))
+	assert.Equal(t, []string{"foo.go:33:12: cannot format json literal: invalid json"}, diagnostics)
+}
+
+func TestFormat_EmbeddedLiterals_TrailingMarker(t *testing.T) {
+	t.Parallel()
+
+	// A trailing marker only applies to the literal on its own line.
+	out, err := golang.Format("foo.go", []byte("package foo\n\n"+
+		"func f() {\n"+
+		"\ta := `{\"a\":1}` //format:json\n"+
+		"\tb := `[1,2]`\n\n"+
+		"\t_, _ = a, b\n"+
+		"}\n"), golang.WithEmbeddedLiterals)
+	assert.NoError(t, err)
+	assert.Equal(t, "package foo\n\n"+
+		"func f() {\n"+
+		"\ta := `{\n"+
+		"\t  \"a\": 1\n"+
+		"\t}` //format:json\n"+
+		"\tb := `[1,2]`\n\n"+
+		"\t_, _ = a, b\n"+
+		"}\n", string(out))
+}
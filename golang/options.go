@@ -0,0 +1,72 @@
+package golang
+
+import (
+	"io/fs"
+
+	gofumpt "mvdan.cc/gofumpt/format"
+)
+
+type options struct {
+	gofumpt  gofumpt.Options
+	fsys     fs.FS
+	filename string
+	report   func(Diagnostic)
+	cache    *importCache
+
+	localPrefixes       []string
+	separateSideEffects bool
+
+	maxLineLength   int
+	docCommentWidth int
+
+	rules    []Rule
+	tags     tagOptions
+	literals literalOptions
+	pipeline []Step
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{gofumpt: FormatOptions}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// groupImports reports whether imports need to be regrouped.
+func (o *options) groupImports() bool {
+	return len(o.localPrefixes) > 0 || o.separateSideEffects
+}
+
+// Option is an option to format go code in a certain way.
+type Option func(o *options)
+
+// WithLocalPrefixes puts imports starting with any of the prefixes into groups after the third party imports.
+// Each prefix gets its own group, in the given order. Existing imports are regrouped accordingly.
+func WithLocalPrefixes(prefixes ...string) Option {
+	return func(o *options) {
+		o.localPrefixes = append(o.localPrefixes, prefixes...)
+	}
+}
+
+// WithSideEffectImportsGroup puts blank and dot imports into a separate group after all other imports.
+var WithSideEffectImportsGroup Option = func(o *options) {
+	o.separateSideEffects = true
+}
+
+// WithoutExtraRules disables gofumpt's extra formatting rules, such as grouping function parameters with repeated types.
+var WithoutExtraRules Option = func(o *options) {
+	o.gofumpt.ExtraRules = false
+}
+
+// WithDocComments reformats the doc comments of exported declarations into the canonical form of Go 1.19,
+// wrapping paragraphs and list items at the given text width.
+// Links like [text](url) are converted into doc links and directives like //go:embed and //nolint are kept.
+// When built with an older version of Go, doc comments are left as they are.
+func WithDocComments(width int) Option {
+	return func(o *options) {
+		o.docCommentWidth = width
+	}
+}
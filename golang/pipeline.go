@@ -0,0 +1,79 @@
+package golang
+
+import (
+	"go/format"
+
+	"github.com/pkg/errors"
+)
+
+// A Step is a step of formatting go code.
+type Step string
+
+// The steps of formatting go code in the order they are run.
+// Only the steps that are configured by other options have an effect, e.g. StepRewrite needs WithRewriteRules.
+const (
+	StepRewrite    Step = "rewrite"
+	StepDirectives Step = "directives"
+	// StepImports adds missing and removes unused imports like goimports and places them in their groups.
+	StepImports Step = "imports"
+	// StepGofmt formats the code like gofmt. It is not part of the default pipeline since gofumpt includes it.
+	StepGofmt        Step = "gofmt"
+	StepGofumpt      Step = "gofumpt"
+	StepLiterals     Step = "embedded literals"
+	StepDocComments  Step = "doc comments"
+	StepLineWrapping Step = "line wrapping"
+	StepStructTags   Step = "struct tags"
+
+	// stepImportGroups regroups the imports as part of StepImports.
+	stepImportGroups Step = "import groups"
+)
+
+// DefaultPipeline are the steps that Format runs by default.
+var DefaultPipeline = []Step{
+	StepRewrite, StepDirectives, StepImports, StepGofumpt,
+	StepLiterals, StepDocComments, StepLineWrapping, StepStructTags,
+}
+
+// WithPipeline only runs the given steps instead of the DefaultPipeline.
+// The steps are always run in their natural order.
+func WithPipeline(steps ...Step) Option {
+	return func(o *options) {
+		o.pipeline = steps
+	}
+}
+
+// FixImports only adds missing and removes unused imports like goimports, which also formats the code like gofmt.
+func FixImports(filepath string, src []byte, opts ...Option) ([]byte, error) {
+	return Format(filepath, src, append(opts[:len(opts):len(opts)], WithPipeline(StepImports))...)
+}
+
+// Gofumpt only formats the code with gofumpt without touching imports or the file system.
+func Gofumpt(src []byte, opts ...Option) ([]byte, error) {
+	return Format("", src, append(opts[:len(opts):len(opts)], WithPipeline(StepGofumpt))...)
+}
+
+// Gofmt only formats the code like gofmt.
+func Gofmt(src []byte) ([]byte, error) {
+	res, err := format.Source(src)
+	return res, errors.Wrap(err, "running 'gofmt'")
+}
+
+// runs reports whether the step is part of the pipeline.
+func (o *options) runs(s Step) bool {
+	if s == stepImportGroups {
+		return o.groupImports() && o.runs(StepImports)
+	}
+
+	pipeline := o.pipeline
+	if pipeline == nil {
+		pipeline = DefaultPipeline
+	}
+
+	for _, p := range pipeline {
+		if p == s {
+			return true
+		}
+	}
+
+	return false
+}
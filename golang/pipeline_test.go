@@ -0,0 +1,71 @@
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+const unfixedImports = `package foo
+var a = 1
+var b = 2
+
+func f() {
+
+	fmt.Println(a, b)
+}
+`
+
+func TestFixImports(t *testing.T) {
+	t.Parallel()
+
+	out, err := golang.FixImports("foo.go", []byte(unfixedImports))
+	assert.NoError(t, err)
+	assert.Equal(t, `package foo
+
+import "fmt"
+
+var a = 1
+var b = 2
+
+func f() {
+
+	fmt.Println(a, b)
+}
+`, string(out))
+}
+
+func TestGofumpt(t *testing.T) {
+	t.Parallel()
+
+	out, err := golang.Gofumpt([]byte(unfixedImports))
+	assert.NoError(t, err)
+	assert.Equal(t, `package foo
+
+var (
+	a = 1
+	b = 2
+)
+
+func f() {
+	fmt.Println(a, b)
+}
+`, string(out))
+}
+
+func TestGofmt(t *testing.T) {
+	t.Parallel()
+
+	out, err := golang.Gofmt([]byte(unfixedImports))
+	assert.NoError(t, err)
+	assert.Equal(t, "package foo\n\nvar a = 1\nvar b = 2\n\nfunc f() {\n\n\tfmt.Println(a, b)\n}\n", string(out))
+
+	_, err = golang.Gofmt([]byte("package"))
+	assert.EqualError(t, err, "running 'gofmt': 1:8: expected 'IDENT', found 'EOF'")
+
+	// The same as a pipeline.
+	out, err = golang.Format("foo.go", []byte(unfixedImports), golang.WithPipeline(golang.StepGofmt))
+	assert.NoError(t, err)
+	assert.Equal(t, "package foo\n\nvar a = 1\nvar b = 2\n\nfunc f() {\n\n\tfmt.Println(a, b)\n}\n", string(out))
+}
@@ -0,0 +1,378 @@
+// The rewriting is adapted from cmd/gofmt/rewrite.go of the Go distribution:
+//
+// Copyright 2009 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found at https://go.dev/LICENSE.
+
+package golang
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+const ruleSeparator = "->"
+
+// A Rule rewrites all expressions matching the pattern into the replacement, like 'gofmt -r'.
+// Single-character lowercase identifiers serve as wildcards matching arbitrary sub-expressions,
+// which will be substituted for the same identifiers in the replacement.
+type Rule struct {
+	Pattern, Replacement ast.Expr
+}
+
+// ParseRule parses a rule of the form "pattern -> replacement", e.g. "interface{} -> any".
+func ParseRule(s string) (Rule, error) {
+	parts := strings.Split(s, ruleSeparator)
+	if len(parts) != 2 { //nolint:gomnd // pattern and replacement
+		return Rule{}, errors.Errorf("rewrite rule %q must be of the form 'pattern -> replacement'", s)
+	}
+
+	pattern, err := parser.ParseExpr(parts[0])
+	if err != nil {
+		return Rule{}, errors.Wrapf(err, "parsing pattern %q", parts[0])
+	}
+
+	replacement, err := parser.ParseExpr(parts[1])
+	if err != nil {
+		return Rule{}, errors.Wrapf(err, "parsing replacement %q", parts[1])
+	}
+
+	return Rule{Pattern: pattern, Replacement: replacement}, nil
+}
+
+// ParseRules parses several rules, see ParseRule.
+func ParseRules(rules ...string) ([]Rule, error) {
+	res := make([]Rule, len(rules))
+
+	for i, s := range rules {
+		r, err := ParseRule(s)
+		if err != nil {
+			return nil, err
+		}
+
+		res[i] = r
+	}
+
+	return res, nil
+}
+
+// WithRewriteRules applies the rules before the imports are fixed and the code is formatted.
+// This way, any imports that are needed or no longer needed after the rewrite are added or removed.
+func WithRewriteRules(rules ...Rule) Option {
+	return func(o *options) {
+		o.rules = append(o.rules, rules...)
+	}
+}
+
+// Rewrite applies the rules to src in the given order.
+// The result is formatted with gofmt, but imports are not fixed.
+func Rewrite(src []byte, rules ...Rule) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing")
+	}
+
+	for _, r := range rules {
+		f = r.rewriteFile(fset, f)
+	}
+
+	b := &bytes.Buffer{}
+	if err := format.Node(b, fset, f); err != nil {
+		return nil, errors.Wrap(err, "printing rewritten code")
+	}
+
+	return b.Bytes(), nil
+}
+
+// The following is based on cmd/gofmt/rewrite.go.
+
+// rewriteFile applies the rule to an entire file.
+func (r Rule) rewriteFile(fset *token.FileSet, f *ast.File) *ast.File {
+	cmap := ast.NewCommentMap(fset, f, f.Comments)
+	m := map[string]reflect.Value{}
+	pat := reflect.ValueOf(r.Pattern)
+	repl := reflect.ValueOf(r.Replacement)
+
+	var rewriteVal func(val reflect.Value) reflect.Value
+	rewriteVal = func(val reflect.Value) reflect.Value {
+		// Don't bother if val is invalid to start with.
+		if !val.IsValid() {
+			return reflect.Value{}
+		}
+
+		val = apply(rewriteVal, val)
+
+		for k := range m {
+			delete(m, k)
+		}
+
+		if match(m, pat, val) {
+			//nolint:forcetypeassert // only nodes can match a pattern
+			val = subst(m, repl, reflect.ValueOf(val.Interface().(ast.Node).Pos()))
+		}
+
+		return val
+	}
+
+	//nolint:forcetypeassert // the file is still a file
+	res := apply(rewriteVal, reflect.ValueOf(f)).Interface().(*ast.File)
+	res.Comments = cmap.Filter(res).Comments() // Recreate comments list.
+
+	return res
+}
+
+// set is a wrapper for x.Set(y); it protects the caller from panics if x cannot be changed to y.
+func set(x, y reflect.Value) {
+	// Don't bother if x cannot be set or y is invalid.
+	if !x.CanSet() || !y.IsValid() {
+		return
+	}
+
+	defer func() {
+		if x := recover(); x != nil {
+			if s, ok := x.(string); ok &&
+				(strings.Contains(s, "type mismatch") || strings.Contains(s, "not assignable")) {
+				// x cannot be set to y - ignore this rewrite.
+				return
+			}
+
+			panic(x)
+		}
+	}()
+
+	x.Set(y)
+}
+
+// Values/types for special cases.
+var (
+	objectPtrNil = reflect.ValueOf((*ast.Object)(nil))
+	scopePtrNil  = reflect.ValueOf((*ast.Scope)(nil))
+
+	identType     = reflect.TypeOf((*ast.Ident)(nil))
+	objectPtrType = reflect.TypeOf((*ast.Object)(nil))
+	positionType  = reflect.TypeOf(token.NoPos)
+	callExprType  = reflect.TypeOf((*ast.CallExpr)(nil))
+	scopePtrType  = reflect.TypeOf((*ast.Scope)(nil))
+)
+
+// apply replaces each AST field x in val with f(x), returning val.
+// To avoid extra conversions, f operates on the reflect.Value form.
+func apply(f func(reflect.Value) reflect.Value, val reflect.Value) reflect.Value {
+	if !val.IsValid() {
+		return reflect.Value{}
+	}
+
+	// *ast.Objects introduce cycles and are likely incorrect after
+	// rewrite; don't follow them but replace with nil instead.
+	if val.Type() == objectPtrType {
+		return objectPtrNil
+	}
+
+	// Similarly for scopes: they are likely incorrect after a rewrite;
+	// replace them with nil.
+	if val.Type() == scopePtrType {
+		return scopePtrNil
+	}
+
+	switch v := reflect.Indirect(val); v.Kind() {
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			e := v.Index(i)
+			set(e, f(e))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			e := v.Field(i)
+			set(e, f(e))
+		}
+	case reflect.Interface:
+		e := v.Elem()
+		set(v, f(e))
+	}
+
+	return val
+}
+
+func isWildcard(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return size == len(s) && unicode.IsLower(r)
+}
+
+// match reports whether pattern matches val, recording wildcard submatches in m.
+// If m == nil, match checks whether pattern == val.
+//
+//nolint:cyclop,gocognit // taken as is from gofmt
+func match(m map[string]reflect.Value, pattern, val reflect.Value) bool {
+	// Wildcard matches any expression. If it appears multiple
+	// times in the pattern, it must match the same expression
+	// each time.
+	if m != nil && pattern.IsValid() && pattern.Type() == identType {
+		//nolint:forcetypeassert // type was checked
+		name := pattern.Interface().(*ast.Ident).Name
+		if isWildcard(name) && val.IsValid() {
+			// Wildcards only match valid (non-nil) expressions.
+			if _, ok := val.Interface().(ast.Expr); ok && !val.IsNil() {
+				if old, ok := m[name]; ok {
+					return match(nil, old, val)
+				}
+
+				m[name] = val
+
+				return true
+			}
+		}
+	}
+
+	// Otherwise, pattern and val must match recursively.
+	if !pattern.IsValid() || !val.IsValid() {
+		return !pattern.IsValid() && !val.IsValid()
+	}
+
+	if pattern.Type() != val.Type() {
+		return false
+	}
+
+	// Special cases.
+	switch pattern.Type() {
+	case identType:
+		// For identifiers, only the names need to match
+		// (and none of the other *ast.Object information).
+		// This is a common case, handle it all here instead
+		// of recursing down any further via reflection.
+		p, _ := pattern.Interface().(*ast.Ident)
+		v, _ := val.Interface().(*ast.Ident)
+
+		return p == nil && v == nil || p != nil && v != nil && p.Name == v.Name
+	case objectPtrType, positionType:
+		// Object pointers and token positions always match.
+		return true
+	case callExprType:
+		// For calls, the Ellipsis fields (token.Pos) must
+		// match since that is how f(x) and f(x...) are different.
+		// Check them here but fall through for the remaining fields.
+		p, _ := pattern.Interface().(*ast.CallExpr)
+		v, _ := val.Interface().(*ast.CallExpr)
+
+		if p.Ellipsis.IsValid() != v.Ellipsis.IsValid() {
+			return false
+		}
+	}
+
+	p := reflect.Indirect(pattern)
+	v := reflect.Indirect(val)
+
+	if !p.IsValid() || !v.IsValid() {
+		return !p.IsValid() && !v.IsValid()
+	}
+
+	switch p.Kind() {
+	case reflect.Slice:
+		if p.Len() != v.Len() {
+			return false
+		}
+
+		for i := 0; i < p.Len(); i++ {
+			if !match(m, p.Index(i), v.Index(i)) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Struct:
+		for i := 0; i < p.NumField(); i++ {
+			if !match(m, p.Field(i), v.Field(i)) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Interface:
+		return match(m, p.Elem(), v.Elem())
+	default:
+		// Handle token integers, etc.
+		return p.Interface() == v.Interface()
+	}
+}
+
+// subst returns a copy of pattern with values from m substituted in place
+// of wildcards and pos used as the position of tokens from the pattern.
+// If m == nil, subst returns a copy of pattern and doesn't change the line
+// number information.
+func subst(m map[string]reflect.Value, pattern, pos reflect.Value) reflect.Value {
+	if !pattern.IsValid() {
+		return reflect.Value{}
+	}
+
+	// Wildcard gets replaced with map value.
+	if m != nil && pattern.Type() == identType {
+		//nolint:forcetypeassert // type was checked
+		name := pattern.Interface().(*ast.Ident).Name
+		if isWildcard(name) {
+			if old, ok := m[name]; ok {
+				return subst(nil, old, reflect.Value{})
+			}
+		}
+	}
+
+	if pos.IsValid() && pattern.Type() == positionType {
+		// Use new position only if old position was valid in the first place.
+		//nolint:forcetypeassert // type was checked
+		if old := pattern.Interface().(token.Pos); !old.IsValid() {
+			return pattern
+		}
+
+		return pos
+	}
+
+	// Otherwise copy.
+	switch p := pattern; p.Kind() {
+	case reflect.Slice:
+		if p.IsNil() {
+			// Do not turn nil slices into empty slices. go/ast
+			// guarantees that certain lists will be nil if not
+			// populated.
+			return reflect.Zero(p.Type())
+		}
+
+		v := reflect.MakeSlice(p.Type(), p.Len(), p.Len())
+		for i := 0; i < p.Len(); i++ {
+			v.Index(i).Set(subst(m, p.Index(i), pos))
+		}
+
+		return v
+	case reflect.Struct:
+		v := reflect.New(p.Type()).Elem()
+		for i := 0; i < p.NumField(); i++ {
+			v.Field(i).Set(subst(m, p.Field(i), pos))
+		}
+
+		return v
+	case reflect.Ptr:
+		v := reflect.New(p.Type()).Elem()
+		if elem := p.Elem(); elem.IsValid() {
+			v.Set(subst(m, elem, pos).Addr())
+		}
+
+		return v
+	case reflect.Interface:
+		v := reflect.New(p.Type()).Elem()
+		if elem := p.Elem(); elem.IsValid() {
+			v.Set(subst(m, elem, pos))
+		}
+
+		return v
+	default:
+		return pattern
+	}
+}
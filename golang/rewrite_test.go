@@ -0,0 +1,96 @@
+package golang_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	beforeRewrite = `package foo
+
+import (
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// Read reads the file.
+func Read(path string) (interface{}, error) {
+	b, err := ioutil.ReadFile(path) // read it
+	if err != nil {
+		return nil, errors.Wrap(err, "reading")
+	}
+
+	return b, nil
+}
+`
+
+	afterRewrite = `package foo
+
+import (
+	"fmt"
+	"os"
+)
+
+// Read reads the file.
+func Read(path string) (any, error) {
+	b, err := os.ReadFile(path) // read it
+	if err != nil {
+		return nil, fmt.Errorf("reading"+": %w", err)
+	}
+
+	return b, nil
+}
+`
+)
+
+func TestFormat_WithRewriteRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := golang.ParseRules(
+		"interface{} -> any",
+		"ioutil.ReadFile(a) -> os.ReadFile(a)",
+		`errors.Wrap(e, m) -> fmt.Errorf(m + ": %w", e)`,
+	)
+	require.NoError(t, err)
+
+	out, err := golang.Format("", []byte(beforeRewrite), golang.WithRewriteRules(rules...))
+	assert.NoError(t, err)
+	assert.Equal(t, afterRewrite, string(out))
+}
+
+func TestParseRule_Error(t *testing.T) {
+	t.Parallel()
+
+	_, err := golang.ParseRule("interface{}")
+	assert.EqualError(t, err, `rewrite rule "interface{}" must be of the form 'pattern -> replacement'`)
+
+	_, err = golang.ParseRule("a -> (")
+	assert.EqualError(t, err, `parsing replacement " (": 1:3: expected operand, found 'EOF'`)
+}
+
+func TestFormat_WithRewriteRules_NilSource(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "read.go")
+	require.NoError(t, os.WriteFile(path, []byte(beforeRewrite), 0o600))
+
+	rules, err := golang.ParseRules(
+		"interface{} -> any",
+		"ioutil.ReadFile(a) -> os.ReadFile(a)",
+		`errors.Wrap(e, m) -> fmt.Errorf(m + ": %w", e)`,
+	)
+	require.NoError(t, err)
+
+	out, err := golang.Format(path, nil, golang.WithRewriteRules(rules...))
+	require.NoError(t, err)
+	assert.Equal(t, afterRewrite, string(out))
+
+	_, err = golang.Format("missing.go", nil, golang.WithRewriteRules(rules...))
+	assert.EqualError(t, err, "reading: open missing.go: no such file or directory")
+}
@@ -0,0 +1,443 @@
+package golang
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	gofumpt "mvdan.cc/gofumpt/format"
+)
+
+// Case is a naming convention for tag names that are derived from field names.
+type Case int
+
+const (
+	// CamelCase derives "userId" from "UserID".
+	CamelCase Case = iota
+	// GoCamelCase keeps initialisms and derives "userID" from "UserID".
+	GoCamelCase
+	// SnakeCase derives "user_id" from "UserID".
+	SnakeCase
+	// KebabCase derives "user-id" from "UserID".
+	KebabCase
+)
+
+// WithTagOrder sorts the keys of struct tags in the given order, e.g. json, yaml, mapstructure, validate.
+// Keys that are not listed follow in their original order.
+// Malformed tags are fixed if possible and reported as diagnostics, see WithDiagnostics.
+func WithTagOrder(keys ...string) Option {
+	return func(o *options) {
+		o.tags.order = append(o.tags.order, keys...)
+		o.tags.enabled = true
+	}
+}
+
+// WithTagAlignment aligns the keys of struct tags of consecutive fields.
+var WithTagAlignment Option = func(o *options) {
+	o.tags.align = true
+	o.tags.enabled = true
+}
+
+// WithMissingTags adds tags with the given keys, e.g. json and yaml, to all exported fields that don't have them.
+// The names are derived from the field names using the case convention.
+func WithMissingTags(c Case, keys ...string) Option {
+	return func(o *options) {
+		o.tags.add = append(o.tags.add, keys...)
+		o.tags.nameCase = c
+		o.tags.enabled = true
+	}
+}
+
+type tagOptions struct {
+	enabled bool
+
+	order    []string
+	align    bool
+	add      []string
+	nameCase Case
+}
+
+type tagPair struct{ key, value string }
+
+// formatTags canonicalises all struct tags.
+func (o *options) formatTags(src []byte) ([]byte, error) {
+	if !o.tags.enabled {
+		return src, nil
+	}
+
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, o.filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing")
+	}
+
+	// The new tags of the fields, which may not have had a tag before.
+	tags := map[*ast.Field]string{}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if st, ok := n.(*ast.StructType); ok {
+			for _, fields := range alignedFields(fset, st.Fields.List) {
+				o.formatFieldTags(fset, fields, tags)
+			}
+		}
+
+		return true
+	})
+
+	if len(tags) == 0 {
+		return src, nil
+	}
+
+	res, err := gofumpt.Source(replaceTags(fset, src, f, tags), o.gofumpt)
+
+	return res, errors.Wrap(err, "formatting struct tags")
+}
+
+// alignedFields splits the fields into blocks that gofmt aligns, i.e. fields on consecutive lines.
+func alignedFields(fset *token.FileSet, fields []*ast.Field) (blocks [][]*ast.Field) {
+	line := func(p token.Pos) int { return fset.Position(p).Line }
+
+	for i, field := range fields {
+		if i == 0 || line(field.Pos()) != line(fields[i-1].End())+1 {
+			blocks = append(blocks, nil)
+		}
+
+		blocks[len(blocks)-1] = append(blocks[len(blocks)-1], field)
+	}
+
+	return blocks
+}
+
+func (o *options) formatFieldTags(fset *token.FileSet, fields []*ast.Field, tags map[*ast.Field]string) {
+	pairs := make([][]tagPair, len(fields))
+
+	for i, field := range fields {
+		if field.Tag != nil {
+			pos := fset.Position(field.Tag.Pos())
+
+			var err error
+			if pairs[i], err = o.parseTag(pos, field.Tag.Value); err != nil {
+				o.reportf(pos, "cannot parse struct tag %s: %v", field.Tag.Value, err)
+				return
+			}
+		}
+
+		pairs[i] = o.addMissingTags(field, pairs[i])
+		pairs[i] = o.sortTag(pairs[i])
+	}
+
+	widths := o.tagWidths(pairs)
+
+	for i, field := range fields {
+		if len(pairs[i]) > 0 {
+			tags[field] = quoteTag(writeTag(pairs[i], widths))
+		}
+	}
+}
+
+// parseTag parses a struct tag leniently, reporting anything that had to be fixed.
+func (o *options) parseTag(pos token.Position, lit string) ([]tagPair, error) {
+	tag, err := strconv.Unquote(lit)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		pairs     []tagPair
+		malformed bool
+		seen      = map[string]bool{}
+	)
+
+	for tag = strings.TrimLeft(tag, " "); tag != ""; {
+		if tag[0] == ',' || unicode.IsSpace(rune(tag[0])) && tag[0] != ' ' {
+			// Pairs must be separated by spaces only.
+			tag, malformed = tag[1:], true
+			continue
+		}
+
+		i := strings.IndexAny(tag, ": \t\"")
+		if i <= 0 {
+			return nil, errors.Errorf("missing key in %q", tag)
+		}
+
+		key := tag[:i]
+
+		rest := strings.TrimLeft(tag[i:], " \t")
+		if !strings.HasPrefix(rest, ":") {
+			return nil, errors.Errorf("missing colon after key %q", key)
+		}
+
+		if rest = strings.TrimLeft(rest[1:], " \t"); len(rest) != len(tag[i+1:]) || tag[i] != ':' {
+			malformed = true
+		}
+
+		value, n, ok := parseTagValue(rest)
+		if !ok {
+			malformed = true
+		}
+
+		if seen[key] {
+			o.reportf(pos, "duplicate key %q in struct tag", key)
+		}
+
+		seen[key] = true
+		pairs = append(pairs, tagPair{key: key, value: value})
+
+		if tag = rest[n:]; tag != "" && tag[0] != ' ' {
+			malformed = true
+		}
+
+		tag = strings.TrimLeft(tag, " ")
+	}
+
+	if malformed {
+		o.reportf(pos, "fixed malformed struct tag %s", lit)
+	}
+
+	return pairs, nil
+}
+
+// parseTagValue parses the value of a key, which should be a quoted string.
+// It returns the value, the number of bytes read, and whether the value was well-formed.
+func parseTagValue(s string) (string, int, bool) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		if quoted, err := strconv.QuotedPrefix(s); err == nil {
+			value, _ := strconv.Unquote(quoted)
+			return value, len(quoted), true
+		}
+
+		// Missing closing quote.
+		if i := strings.IndexAny(s[1:], " \t"); i >= 0 {
+			return s[1 : i+1], i + 1, false
+		}
+
+		return s[1:], len(s), false
+	case strings.HasPrefix(s, "'"):
+		if i := strings.IndexByte(s[1:], '\''); i >= 0 {
+			return s[1 : i+1], i + 2, false //nolint:gomnd // two quotes
+		}
+
+		return s[1:], len(s), false
+	default:
+		i := strings.IndexAny(s, " \t,")
+		if i < 0 {
+			i = len(s)
+		}
+
+		return s[:i], i, false
+	}
+}
+
+func (o *options) addMissingTags(field *ast.Field, pairs []tagPair) []tagPair {
+	// Only add tags to exported, named fields with a single name.
+	if len(o.tags.add) == 0 || len(field.Names) != 1 || !field.Names[0].IsExported() {
+		return pairs
+	}
+
+	for _, key := range o.tags.add {
+		if !hasTagKey(pairs, key) {
+			pairs = append(pairs, tagPair{key: key, value: convertCase(field.Names[0].Name, o.tags.nameCase)})
+		}
+	}
+
+	return pairs
+}
+
+func hasTagKey(pairs []tagPair, key string) bool {
+	for _, p := range pairs {
+		if p.key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortTag sorts the pairs in the configured order, keeping the original order otherwise.
+func (o *options) sortTag(pairs []tagPair) []tagPair {
+	if len(o.tags.order) == 0 {
+		return pairs
+	}
+
+	sorted := make([]tagPair, 0, len(pairs))
+
+	for _, key := range o.tags.order {
+		for _, p := range pairs {
+			if p.key == key {
+				sorted = append(sorted, p)
+			}
+		}
+	}
+
+	for _, p := range pairs {
+		if !contains(o.tags.order, p.key) {
+			sorted = append(sorted, p)
+		}
+	}
+
+	return sorted
+}
+
+func contains(list []string, s string) bool {
+	for _, el := range list {
+		if el == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tagWidths returns the width of each position of the tags if they are to be aligned.
+func (o *options) tagWidths(pairs [][]tagPair) []int {
+	if !o.tags.align {
+		return nil
+	}
+
+	var widths []int
+
+	for _, tag := range pairs {
+		for i, p := range tag {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+
+			if w := len(p.String()); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	return widths
+}
+
+func (p tagPair) String() string { return p.key + ":" + strconv.Quote(p.value) }
+
+func writeTag(pairs []tagPair, widths []int) string {
+	b := &strings.Builder{}
+
+	for i, p := range pairs {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+
+		s := p.String()
+		b.WriteString(s)
+
+		if i < len(widths) && i < len(pairs)-1 {
+			b.WriteString(strings.Repeat(" ", widths[i]-len(s)))
+		}
+	}
+
+	return b.String()
+}
+
+// quoteTag returns the tag as a raw string literal if possible.
+func quoteTag(tag string) string {
+	if strings.Contains(tag, "`") {
+		return strconv.Quote(tag)
+	}
+
+	return "`" + tag + "`"
+}
+
+// replaceTags writes the new tags into src.
+func replaceTags(fset *token.FileSet, src []byte, f *ast.File, tags map[*ast.Field]string) []byte {
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+
+	b := &bytes.Buffer{}
+	last := 0
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok {
+			return true
+		}
+
+		tag, ok := tags[field]
+		if !ok {
+			return true
+		}
+
+		if field.Tag == nil {
+			// Add the tag after the type.
+			end := offset(field.Type.End())
+			b.Write(src[last:end])
+			b.WriteString(" " + tag)
+
+			last = end
+		} else {
+			b.Write(src[last:offset(field.Tag.Pos())])
+			b.WriteString(tag)
+
+			last = offset(field.Tag.End())
+		}
+
+		return true
+	})
+
+	b.Write(src[last:])
+
+	return b.Bytes()
+}
+
+// convertCase converts a go identifier into the given case.
+func convertCase(name string, c Case) string {
+	words := splitWords(name)
+
+	for i, w := range words {
+		switch {
+		case c == SnakeCase, c == KebabCase, i == 0:
+			words[i] = strings.ToLower(w)
+		case c == GoCamelCase && strings.ToUpper(w) == w:
+			// Keep initialisms.
+		default:
+			words[i] = strings.ToUpper(w[:1]) + strings.ToLower(w[1:])
+		}
+	}
+
+	switch c {
+	case SnakeCase:
+		return strings.Join(words, "_")
+	case KebabCase:
+		return strings.Join(words, "-")
+	default:
+		return strings.Join(words, "")
+	}
+}
+
+// splitWords splits an identifier like "HTTPServerID" into words like "HTTP", "Server" and "ID".
+func splitWords(name string) (words []string) {
+	runes := []rune(name)
+	start := 0
+
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+
+		switch {
+		case cur == '_':
+			words = append(words, string(runes[start:i]))
+			start = i + 1
+		case unicode.IsUpper(cur) && (unicode.IsLower(prev) || unicode.IsDigit(prev)),
+			unicode.IsUpper(prev) && unicode.IsUpper(cur) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+			if start < i {
+				words = append(words, string(runes[start:i]))
+			}
+
+			start = i
+		}
+	}
+
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
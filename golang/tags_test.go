@@ -0,0 +1,83 @@
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	unorderedTags = `package foo
+
+type Config struct {
+	UserID    string ` + "`" + `validate:"required"   yaml:"user_id"  json:"userId"` + "`" + `
+	Name string ` + "`" + `json: "name",yaml:'name'` + "`" + `
+	HTTPServer string
+	Dup int ` + "`" + `json:"dup" json:"dup2"` + "`" + `
+
+	internal int
+	Embedded
+}
+`
+
+	orderedTags = `package foo
+
+type Config struct {
+	UserID     string ` + "`" + `json:"userId"     yaml:"user_id"    validate:"required"` + "`" + `
+	Name       string ` + "`" + `json:"name"       yaml:"name"` + "`" + `
+	HTTPServer string ` + "`" + `json:"httpServer" yaml:"httpServer"` + "`" + `
+	Dup        int    ` + "`" + `json:"dup"        json:"dup2"       yaml:"dup"` + "`" + `
+
+	internal int
+	Embedded
+}
+`
+)
+
+func TestFormat_Tags(t *testing.T) {
+	t.Parallel()
+
+	var diagnostics []string
+
+	opts := []golang.Option{
+		golang.WithTagOrder("json", "yaml", "mapstructure", "validate"),
+		golang.WithTagAlignment,
+		golang.WithMissingTags(golang.CamelCase, "json", "yaml"),
+		golang.WithDiagnostics(func(d golang.Diagnostic) {
+			diagnostics = append(diagnostics, d.String())
+		}),
+	}
+
+	out, err := golang.Format("foo.go", []byte(unorderedTags), opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, orderedTags, string(out))
+	assert.Equal(t, []string{
+		"foo.go:5:20: fixed malformed struct tag `json: \"name\",yaml:'name'`",
+		`foo.go:7:17: duplicate key "json" in struct tag`,
+	}, diagnostics)
+
+	// Formatting again doesn't change anything.
+	diagnostics = nil
+
+	out, err = golang.Format("foo.go", out, opts...)
+	assert.NoError(t, err)
+	assert.Equal(t, orderedTags, string(out))
+	assert.Equal(t, []string{`foo.go:7:20: duplicate key "json" in struct tag`}, diagnostics)
+}
+
+func TestFormat_TagCases(t *testing.T) {
+	t.Parallel()
+
+	for c, want := range map[golang.Case]string{
+		golang.CamelCase:   "httpServerId",
+		golang.GoCamelCase: "httpServerID",
+		golang.SnakeCase:   "http_server_id",
+		golang.KebabCase:   "http-server-id",
+	} {
+		out, err := golang.Format("", []byte("package foo\ntype Foo struct{ HTTPServerID int }\n"),
+			golang.WithMissingTags(c, "json"))
+		assert.NoError(t, err)
+		assert.Equal(t, "package foo\n\ntype Foo struct {\n\tHTTPServerID int `json:\""+want+"\"`\n}\n", string(out))
+	}
+}
@@ -0,0 +1,189 @@
+package golang
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	gofumpt "mvdan.cc/gofumpt/format"
+)
+
+// tabWidth is the width of a tab when measuring the length of a line.
+const tabWidth = 4
+
+// WithMaxLineLength wraps lines that are longer than the given length, counting tabs as four characters.
+// Function signatures, including the type parameters of generic functions, argument lists of calls and composite
+// literals are wrapped by putting each element on its own line, starting with the outermost.
+// Lists containing comments are not wrapped to keep the comments intact.
+// Lines that can't be wrapped, e.g. due to long struct tags or string literals, are left as they are.
+// Struct tags are never split since reflect.StructTag does not allow line breaks.
+func WithMaxLineLength(length int) Option {
+	return func(o *options) {
+		o.maxLineLength = length
+	}
+}
+
+// wrapLines wraps all lines that are too long.
+func (o *options) wrapLines(src []byte) ([]byte, error) {
+	if o.maxLineLength <= 0 {
+		return src, nil
+	}
+
+	for {
+		fset := token.NewFileSet()
+
+		f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing")
+		}
+
+		l := findWrappable(fset, f, longLines(src, o.maxLineLength))
+		if l == nil {
+			return src, nil
+		}
+
+		if src, err = gofumpt.Source(l.wrap(fset, src), o.gofumpt); err != nil {
+			return nil, errors.Wrap(err, "formatting wrapped lines")
+		}
+	}
+}
+
+// longLines returns the numbers of all lines that are longer than the maximum.
+func longLines(src []byte, max int) map[int]bool {
+	long := map[int]bool{}
+
+	for i, line := range bytes.Split(src, []byte{'\n'}) {
+		length := utf8.RuneCount(line) + bytes.Count(line, []byte{'\t'})*(tabWidth-1)
+		if length > max {
+			long[i+1] = true
+		}
+	}
+
+	return long
+}
+
+// wrappable is a list of elements between an opening and closing token that can be put on separate lines.
+type wrappable struct {
+	opening, closing token.Pos
+	elems            []ast.Node
+	end              token.Pos // The end of the last element.
+}
+
+// findWrappable returns the outermost list on one of the lines that is not wrapped yet.
+func findWrappable(fset *token.FileSet, f *ast.File, lines map[int]bool) (found *wrappable) {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	line := func(p token.Pos) int { return fset.Position(p).Line }
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+
+		for _, l := range newWrappables(n) {
+			if lines[line(l.opening)] && line(l.opening) == line(l.closing) && !hasComments(f, l.opening, l.closing) {
+				found = l
+
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return found
+}
+
+// newWrappables returns the lists of the node that can be wrapped, in the order they should be wrapped.
+func newWrappables(n ast.Node) []*wrappable {
+	switch n := n.(type) {
+	case *ast.CallExpr:
+		if len(n.Args) == 0 {
+			return nil
+		}
+
+		l := &wrappable{opening: n.Lparen, closing: n.Rparen, end: n.Args[len(n.Args)-1].End()}
+		for _, arg := range n.Args {
+			l.elems = append(l.elems, arg)
+		}
+
+		if n.Ellipsis.IsValid() {
+			l.end = n.Ellipsis + token.Pos(len(token.ELLIPSIS.String()))
+		}
+
+		return []*wrappable{l}
+	case *ast.FuncType:
+		// The parameters are wrapped before the type parameters of generic functions.
+		var ls []*wrappable
+
+		for _, fl := range []*ast.FieldList{n.Params, n.TypeParams} {
+			if fl == nil || len(fl.List) == 0 {
+				continue
+			}
+
+			l := &wrappable{opening: fl.Opening, closing: fl.Closing, end: fl.List[len(fl.List)-1].End()}
+			for _, field := range fl.List {
+				l.elems = append(l.elems, field)
+			}
+
+			ls = append(ls, l)
+		}
+
+		return ls
+	case *ast.CompositeLit:
+		if len(n.Elts) == 0 {
+			return nil
+		}
+
+		l := &wrappable{opening: n.Lbrace, closing: n.Rbrace, end: n.Elts[len(n.Elts)-1].End()}
+		for _, el := range n.Elts {
+			l.elems = append(l.elems, el)
+		}
+
+		return []*wrappable{l}
+	default:
+		return nil
+	}
+}
+
+func hasComments(f *ast.File, from, to token.Pos) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() > from && cg.End() <= to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wrap puts each element on its own line and adds a trailing comma.
+func (l *wrappable) wrap(fset *token.FileSet, src []byte) []byte {
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+
+	b := &bytes.Buffer{}
+	last := offset(l.opening) + 1
+
+	b.Write(src[:last])
+
+	for _, el := range l.elems {
+		start := offset(el.Pos())
+
+		b.Write(bytes.TrimRight(src[last:start], " "))
+		b.WriteByte('\n')
+
+		last = start
+	}
+
+	end := offset(l.end)
+
+	b.Write(src[last:end])
+	b.WriteString(",\n")
+	b.Write(src[offset(l.closing):])
+
+	return b.Bytes()
+}
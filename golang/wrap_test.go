@@ -0,0 +1,120 @@
+package golang_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	longLines = `package foo
+
+import "context"
+
+type Tags struct {
+	Field string ` + "`" + `json:"field,omitempty" yaml:"field,omitempty" mapstructure:"field" validate:"required"` + "`" + `
+}
+
+func (c *Client) GetAudienceSegmentsWithResponse(ctx context.Context, audienceID string, params *Params) (*Response, error) {
+	return c.do(ctx, "GET", "/audiences/"+audienceID+"/segments", params, []string{"application/json", "text/plain"}) // the call
+}
+
+var defaults = map[string]int{"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7}
+`
+
+	wrappedLines = `package foo
+
+import "context"
+
+type Tags struct {
+	Field string ` + "`" + `json:"field,omitempty" yaml:"field,omitempty" mapstructure:"field" validate:"required"` + "`" + `
+}
+
+func (c *Client) GetAudienceSegmentsWithResponse(
+	ctx context.Context,
+	audienceID string,
+	params *Params,
+) (*Response, error) {
+	return c.do(
+		ctx,
+		"GET",
+		"/audiences/"+audienceID+"/segments",
+		params,
+		[]string{"application/json", "text/plain"},
+	) // the call
+}
+
+var defaults = map[string]int{
+	"one":   1,
+	"two":   2,
+	"three": 3,
+	"four":  4,
+	"five":  5,
+	"six":   6,
+	"seven": 7,
+}
+`
+)
+
+func TestFormat_MaxLineLength(t *testing.T) {
+	t.Parallel()
+
+	out, err := golang.Format("", []byte(longLines), golang.WithMaxLineLength(80))
+	assert.NoError(t, err)
+	assert.Equal(t, wrappedLines, string(out))
+
+	// Wrapping is idempotent.
+	out, err = golang.Format("", out, golang.WithMaxLineLength(80))
+	assert.NoError(t, err)
+	assert.Equal(t, wrappedLines, string(out))
+}
+
+func TestFormat_MaxLineLength_Generics(t *testing.T) {
+	t.Parallel()
+
+	src := "package foo\n\n" +
+		"func Reduce[Element any, Accumulator any, OtherParameter comparable](items []Element, acc Accumulator) {\n}\n"
+
+	want := "package foo\n\n" +
+		"func Reduce[\n" +
+		"\tElement any,\n" +
+		"\tAccumulator any,\n" +
+		"\tOtherParameter comparable,\n" +
+		"](\n" +
+		"\titems []Element,\n" +
+		"\tacc Accumulator,\n" +
+		") {\n}\n"
+
+	out, err := golang.Format("", []byte(src), golang.WithMaxLineLength(60))
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(out))
+
+	out, err = golang.Format("", out, golang.WithMaxLineLength(60))
+	assert.NoError(t, err)
+	assert.Equal(t, want, string(out))
+}
+
+func TestFormat_MaxLineLength_Comments(t *testing.T) {
+	t.Parallel()
+
+	// Lists with comments, also right before the closing token, are left intact.
+	src := "package foo\n\n" +
+		"func f() {\n\tgetSomething(alpha, beta, gamma, delta, epsilon /* c */)\n}\n"
+
+	out, err := golang.Format("", []byte(src), golang.WithMaxLineLength(40))
+	assert.NoError(t, err)
+	assert.Equal(t, src, string(out))
+}
+
+func TestFormat_MaxLineLength_StructTags(t *testing.T) {
+	t.Parallel()
+
+	// Struct tags can't contain line breaks, so their lines stay long.
+	src := "package foo\n\n" +
+		"type T struct {\n\tField string `json:\"field,omitempty\" yaml:\"field,omitempty\" validate:\"required\"`\n}\n"
+
+	out, err := golang.Format("", []byte(src), golang.WithMaxLineLength(60))
+	assert.NoError(t, err)
+	assert.Equal(t, src, string(out))
+}
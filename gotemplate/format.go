@@ -0,0 +1,302 @@
+// Package gotemplate formats text/template and html/template files.
+package gotemplate
+
+import (
+	"strings"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	leftDelim    = "{{"
+	rightDelim   = "}}"
+	leftTrim     = "- "
+	rightTrim    = " -"
+	trimMarker   = '-'
+	spaces       = " \t\r\n"
+	leftComment  = "/*"
+	rightComment = "*/"
+)
+
+// Format formats a go template.
+//
+// Actions are written as "{{ .Foo }}" (see WithCompactActions) with single spaces between their words
+// and trim markers are kept. Lines whose indentation is removed by a trim marker, e.g. lines starting with "{{-",
+// are indented according to the nesting of if, range, with, define and block actions.
+// All other text is left as it is since it is part of the output.
+func Format(src []byte, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+
+	t := parse.New("")
+	t.Mode = parse.ParseComments | parse.SkipFuncCheck
+
+	if _, err := t.Parse(string(src), "", "", map[string]*parse.Tree{}); err != nil {
+		return nil, errors.Wrap(err, "parsing template")
+	}
+
+	items, err := lex(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(o.print(items)), nil
+}
+
+// An item is either text or an action.
+type item struct {
+	text string
+
+	isAction            bool
+	trimLeft, trimRight bool
+	isComment           bool
+	words               []string
+}
+
+// keyword returns the first word of an action.
+func (it item) keyword() string {
+	if it.isComment || len(it.words) == 0 {
+		return ""
+	}
+
+	return it.words[0]
+}
+
+// lex splits the template into text and actions.
+func lex(src string) (items []item, err error) {
+	for src != "" {
+		i := strings.Index(src, leftDelim)
+		if i < 0 {
+			return append(items, item{text: src}), nil
+		}
+
+		if i > 0 {
+			items = append(items, item{text: src[:i]})
+		}
+
+		it, n, err := lexAction(src[i:])
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, it)
+		src = src[i+n:]
+	}
+
+	return items, nil
+}
+
+// lexAction lexes the action at the start of s and returns the number of bytes read.
+func lexAction(s string) (item, int, error) {
+	it := item{isAction: true}
+	inner := s[len(leftDelim):]
+
+	// The trim marker must be followed by whitespace.
+	if len(inner) > 1 && inner[0] == trimMarker && isSpace(inner[1]) {
+		it.trimLeft = true
+		inner = strings.TrimLeft(inner[1:], spaces)
+	}
+
+	if strings.HasPrefix(inner, leftComment) {
+		end := strings.Index(inner, rightComment)
+		if end < 0 {
+			return item{}, 0, errors.New("unclosed comment")
+		}
+
+		it.isComment = true
+		it.words = []string{inner[:end+len(rightComment)]}
+		inner = strings.TrimLeft(inner[end+len(rightComment):], spaces)
+	} else {
+		words, n, err := lexWords(inner)
+		if err != nil {
+			return item{}, 0, err
+		}
+
+		it.words = words
+		inner = inner[n:]
+	}
+
+	switch {
+	case strings.HasPrefix(inner, string(trimMarker)+rightDelim):
+		it.trimRight = true
+		inner = inner[len(rightDelim)+1:]
+	case strings.HasPrefix(inner, rightDelim):
+		inner = inner[len(rightDelim):]
+	default:
+		return item{}, 0, errors.New("unclosed action")
+	}
+
+	return it, len(s) - len(inner), nil
+}
+
+// lexWords splits the pipeline of an action into words up to the closing delimiter or its trim marker,
+// keeping string and character literals intact.
+func lexWords(s string) (words []string, n int, err error) {
+	word := &strings.Builder{}
+
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for n < len(s) {
+		switch c := s[n]; {
+		case strings.HasPrefix(s[n:], rightDelim),
+			c == trimMarker && strings.HasPrefix(s[n+1:], rightDelim) && (n == 0 || isSpace(s[n-1])):
+			flush()
+			return words, n, nil
+		case isSpace(c):
+			flush()
+			n++
+		case c == '|':
+			// Pipes are separated by spaces.
+			flush()
+
+			words = append(words, "|")
+			n++
+		case c == '"' || c == '\'' || c == '`':
+			l := literalLength(s[n:])
+			if l < 0 {
+				return nil, 0, errors.New("unterminated literal")
+			}
+
+			word.WriteString(s[n : n+l])
+			n += l
+		default:
+			word.WriteByte(c)
+			n++
+		}
+	}
+
+	return nil, 0, errors.New("unclosed action")
+}
+
+func isSpace(c byte) bool { return strings.IndexByte(spaces, c) >= 0 }
+
+// literalLength returns the length of the quoted literal at the start of s or -1 if it is not terminated.
+func literalLength(s string) int {
+	quote := s[0]
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+// print prints the formatted template.
+func (o *options) print(items []item) string {
+	b := &strings.Builder{}
+
+	var (
+		depth       int
+		trimmed     bool // Whether whitespace is currently removed by a trim marker.
+		lineStarted bool // Whether anything other than whitespace was written on the current line.
+	)
+
+	for i, it := range items {
+		if it.isAction {
+			b.WriteString(o.printAction(it))
+
+			switch it.keyword() {
+			case "if", "range", "with", "define", "block":
+				depth++
+			case "end":
+				if depth > 0 {
+					depth--
+				}
+			}
+
+			trimmed, lineStarted = it.trimRight, true
+
+			continue
+		}
+
+		var next *item
+		if i+1 < len(items) {
+			next = &items[i+1]
+		}
+
+		lines := strings.Split(it.text, "\n")
+
+		for j, line := range lines {
+			isLast := j == len(lines)-1
+			blank := strings.TrimLeft(line, spaces) == ""
+
+			if j > 0 {
+				b.WriteByte('\n')
+
+				lineStarted = false
+			}
+
+			// The indentation can be changed if it is removed by a preceding or following trim marker.
+			insensitive := trimmed && !lineStarted || isLast && blank && next != nil && next.trimLeft
+			if !insensitive || lineStarted {
+				b.WriteString(line)
+
+				if !blank {
+					trimmed, lineStarted = false, true
+				}
+
+				continue
+			}
+
+			switch {
+			case !blank:
+				b.WriteString(strings.Repeat(o.indent, depth))
+				b.WriteString(strings.TrimLeft(line, spaces))
+
+				trimmed, lineStarted = false, true
+			case isLast && next != nil:
+				d := depth
+				if kw := next.keyword(); kw == "end" || kw == "else" {
+					d--
+				}
+
+				if d > 0 {
+					b.WriteString(strings.Repeat(o.indent, d))
+				}
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func (o *options) printAction(it item) string {
+	space := " "
+	if o.compact {
+		space = ""
+	}
+
+	b := &strings.Builder{}
+	b.WriteString(leftDelim)
+
+	if it.trimLeft {
+		b.WriteString(leftTrim)
+	} else if !it.isComment {
+		// Comments must start directly after the delimiter.
+		b.WriteString(space)
+	}
+
+	b.WriteString(strings.Join(it.words, " "))
+
+	if it.trimRight {
+		b.WriteString(rightTrim)
+	} else if !it.isComment {
+		b.WriteString(space)
+	}
+
+	b.WriteString(rightDelim)
+
+	return b.String()
+}
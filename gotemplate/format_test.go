@@ -0,0 +1,58 @@
+package gotemplate_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/gotemplate"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	unformattedTemplate = `{{/* The config. */}}
+{{define "config"}}
+{{- range $i, $v := .Items}}
+  {{- if   eq $v.Name "}}"  -}}
+        {{- template "item" $v }}
+{{- else if $v.Enabled}}
+  name: {{$v.Name|printf "%q"}}
+{{- end}}
+{{- end -}}
+{{end}}
+`
+
+	formattedTemplate = `{{/* The config. */}}
+{{ define "config" }}
+	{{- range $i, $v := .Items }}
+		{{- if eq $v.Name "}}" -}}
+			{{- template "item" $v }}
+		{{- else if $v.Enabled }}
+  name: {{ $v.Name | printf "%q" }}
+		{{- end }}
+	{{- end -}}
+{{ end }}
+`
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	out, err := gotemplate.Format([]byte(unformattedTemplate))
+	assert.NoError(t, err)
+	assert.Equal(t, formattedTemplate, string(out))
+
+	// Formatting again doesn't change anything.
+	out, err = gotemplate.Format(out)
+	assert.NoError(t, err)
+	assert.Equal(t, formattedTemplate, string(out))
+
+	out, err = gotemplate.Format([]byte("{{ .Foo }} and {{- .Bar -}}\n"), gotemplate.WithCompactActions)
+	assert.NoError(t, err)
+	assert.Equal(t, "{{.Foo}} and {{- .Bar -}}\n", string(out))
+}
+
+func TestFormat_Error(t *testing.T) {
+	t.Parallel()
+
+	_, err := gotemplate.Format([]byte("{{ if .Foo }}"))
+	assert.EqualError(t, err, "parsing template: template: :1: unexpected EOF")
+}
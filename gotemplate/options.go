@@ -0,0 +1,30 @@
+package gotemplate
+
+// Option is an option for formatting go templates.
+type Option func(o *options)
+
+type options struct {
+	compact bool
+	indent  string
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{indent: "\t"}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithCompactActions writes actions without spaces inside the delimiters, e.g. "{{.Foo}}".
+var WithCompactActions Option = func(o *options) {
+	o.compact = true
+}
+
+// WithIndent sets the string used to indent nested blocks, a tab by default.
+func WithIndent(indent string) Option {
+	return func(o *options) {
+		o.indent = indent
+	}
+}
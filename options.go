@@ -0,0 +1,136 @@
+package format
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/faetools/format/golang"
+	"github.com/faetools/format/yaml"
+)
+
+type options struct {
+	fsys fs.FS
+
+	generatedPolicy   GeneratedPolicy
+	generatedPatterns []string
+
+	goOptions   []golang.Option
+	goPipelines []goPipeline
+
+	yamlOptions     []yaml.EncodeOption
+	yamlFileOptions []yamlFileOptions
+}
+
+type goPipeline struct {
+	pattern string
+	steps   []golang.Step
+}
+
+// goPipeline returns the steps of the first pipeline whose pattern matches the path.
+func (o *options) goPipeline(path string) ([]golang.Step, bool) {
+	for _, p := range o.goPipelines {
+		if matchPath(p.pattern, path) {
+			return p.steps, true
+		}
+	}
+
+	return nil, false
+}
+
+type yamlFileOptions struct {
+	pattern string
+	opts    []yaml.EncodeOption
+}
+
+// yamlOptionsFor returns the options used to format the yaml file.
+func (o *options) yamlOptionsFor(path string) []yaml.EncodeOption {
+	opts := o.yamlOptions
+
+	for _, f := range o.yamlFileOptions {
+		if matchPath(f.pattern, path) {
+			opts = append(opts[:len(opts):len(opts)], f.opts...)
+		}
+	}
+
+	return opts
+}
+
+// matchPath reports whether the path matches the pattern.
+// Patterns without a slash are matched against the file name, all others against the whole path.
+func matchPath(pattern, path string) bool {
+	name := path
+	if !strings.Contains(pattern, "/") {
+		name = filepath.Base(path)
+	}
+
+	ok, _ := filepath.Match(pattern, name)
+
+	return ok
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{generatedPatterns: DefaultGeneratedPatterns}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Option is an option to format files in a certain way.
+type Option func(o *options)
+
+// WithFS makes Format look up files other than the formatted one in fsys instead of on disk,
+// e.g. sidecar files of generated files or the sibling files of go code, see golang.WithFS.
+func WithFS(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fsys = fsys
+	}
+}
+
+// WithGeneratedPolicy sets how generated files are formatted.
+func WithGeneratedPolicy(p GeneratedPolicy) Option {
+	return func(o *options) {
+		o.generatedPolicy = p
+	}
+}
+
+// WithGeneratedPatterns sets the file name patterns of generated files, replacing DefaultGeneratedPatterns.
+func WithGeneratedPatterns(patterns ...string) Option {
+	return func(o *options) {
+		o.generatedPatterns = patterns
+	}
+}
+
+// WithGoOptions sets the options used to format go code.
+func WithGoOptions(opts ...golang.Option) Option {
+	return func(o *options) {
+		o.goOptions = append(o.goOptions, opts...)
+	}
+}
+
+// WithGoPipeline only runs the given steps for go files matching the pattern, see golang.WithPipeline.
+// Patterns without a slash are matched against the file name, all others against the whole path.
+// The first matching pipeline is used.
+func WithGoPipeline(pattern string, steps ...golang.Step) Option {
+	return func(o *options) {
+		o.goPipelines = append(o.goPipelines, goPipeline{pattern: pattern, steps: steps})
+	}
+}
+
+// WithYAMLOptions sets the options used to format yaml files, e.g. yaml.Indent.
+func WithYAMLOptions(opts ...yaml.EncodeOption) Option {
+	return func(o *options) {
+		o.yamlOptions = append(o.yamlOptions, opts...)
+	}
+}
+
+// WithYAMLFileOptions adds options used to format yaml files matching the pattern, e.g. a key order.
+// Patterns are matched like in WithGoPipeline and the options of all matching patterns are used.
+func WithYAMLFileOptions(pattern string, opts ...yaml.EncodeOption) Option {
+	return func(o *options) {
+		o.yamlFileOptions = append(o.yamlFileOptions, yamlFileOptions{pattern: pattern, opts: opts})
+	}
+}
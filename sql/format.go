@@ -0,0 +1,306 @@
+// Package sql formats SQL queries.
+package sql
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// The indentation of conditions and continued clauses.
+const indent = "  "
+
+// clauses start a new line. Multi-word clauses are matched before their first word.
+var clauses = []string{
+	"SELECT", "FROM", "WHERE", "GROUP BY", "ORDER BY", "HAVING", "LIMIT", "OFFSET",
+	"LEFT JOIN", "RIGHT JOIN", "INNER JOIN", "FULL JOIN", "CROSS JOIN", "JOIN",
+	"UNION ALL", "UNION", "INTERSECT", "EXCEPT",
+	"INSERT INTO", "VALUES", "UPDATE", "SET", "DELETE FROM", "RETURNING", "ON CONFLICT", "WITH",
+}
+
+// keywords are written in upper case.
+var keywords = map[string]bool{}
+
+func init() {
+	for _, c := range clauses {
+		for _, w := range strings.Fields(c) {
+			keywords[w] = true
+		}
+	}
+
+	for _, w := range strings.Fields(`ALL AND ANY AS ASC BETWEEN BY CASE DEFAULT DESC DISTINCT DO ELSE END
+		EXISTS FALSE FOR IN INTO IS LIKE ILIKE NOT NOTHING NULL ON OR OUTER THEN TRUE USING WHEN`) {
+		keywords[w] = true
+	}
+}
+
+// A token is a word, symbol, literal or comment.
+type token struct {
+	text   string
+	spaced bool // Whether the token was preceded by whitespace.
+}
+
+// Format formats an SQL query: keywords are upper case, every clause starts on a new line,
+// AND and OR conditions are indented on their own lines and all other whitespace is normalised.
+// Comments, string literals including dollar-quoted ones and quoted identifiers are kept as they are.
+func Format(src []byte) ([]byte, error) {
+	tokens, err := tokenize(string(src))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return src, nil
+	}
+
+	b := &strings.Builder{}
+	depth := 0 // The depth of parentheses.
+
+	newLine := func(extra string) {
+		if s := b.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			b.WriteByte('\n')
+		}
+
+		b.WriteString(strings.Repeat(indent, depth) + extra)
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i].text
+		upper := strings.ToUpper(tok)
+
+		if clause, n := matchClause(tokens[:i], tokens[i:]); n > 0 {
+			newLine("")
+			b.WriteString(clause)
+
+			i += n - 1
+
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(tok, "--"):
+			space(b)
+			b.WriteString(tok + "\n")
+
+			continue
+		case upper == "AND" || upper == "OR":
+			if !isBetweenAnd(tokens[:i]) {
+				newLine(indent)
+				b.WriteString(upper)
+
+				continue
+			}
+		case tok == "(":
+			// Keep function calls together.
+			if tokens[i].spaced || i > 0 && keywords[strings.ToUpper(tokens[i-1].text)] {
+				space(b)
+			}
+
+			b.WriteString(tok)
+
+			depth++
+
+			continue
+		case tok == ")":
+			if depth > 0 {
+				depth--
+			}
+		}
+
+		if keywords[upper] {
+			tok = upper
+		}
+
+		if tok != "," && tok != ")" && tok != ";" && !strings.HasSuffix(b.String(), "(") {
+			space(b)
+		}
+
+		b.WriteString(tok)
+	}
+
+	if !strings.HasSuffix(b.String(), "\n") {
+		b.WriteByte('\n')
+	}
+
+	return []byte(b.String()), nil
+}
+
+// matchClause returns the clause that the tokens start with and the number of its words.
+func matchClause(before, tokens []token) (string, int) {
+	for _, c := range clauses {
+		words := strings.Fields(c)
+		if len(words) > len(tokens) || !isClausePosition(c, before) {
+			continue
+		}
+
+		match := true
+
+		for i, w := range words {
+			if strings.ToUpper(tokens[i].text) != w {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			return c, len(words)
+		}
+	}
+
+	return "", 0
+}
+
+// isClausePosition reports whether the words of the clause start a clause after the tokens before them,
+// e.g. WITH in "timestamp with time zone" or UPDATE in "SELECT … FOR UPDATE" don't.
+func isClausePosition(clause string, before []token) bool {
+	prev := ""
+	if len(before) > 0 {
+		prev = strings.ToUpper(before[len(before)-1].text)
+	}
+
+	switch clause {
+	case "WITH":
+		// Common table expressions start a statement.
+		return prev == "" || prev == ";" || prev == "("
+	case "UPDATE":
+		// Row locks (FOR [NO KEY] UPDATE), upserts (DO UPDATE) and referential actions (ON UPDATE).
+		return prev != "FOR" && prev != "KEY" && prev != "DO" && prev != "ON"
+	case "SET":
+		// Referential actions like ON DELETE SET NULL.
+		return len(before) < 2 || strings.ToUpper(before[len(before)-2].text) != "ON"
+	default:
+		return true
+	}
+}
+
+// isBetweenAnd reports whether an AND belongs to a BETWEEN, i.e. the last keyword before it is BETWEEN.
+func isBetweenAnd(before []token) bool {
+	for i := len(before) - 1; i >= 0; i-- {
+		switch strings.ToUpper(before[i].text) {
+		case "BETWEEN":
+			return true
+		case "AND", "OR", "WHERE", "ON", "HAVING", "(":
+			return false
+		}
+	}
+
+	return false
+}
+
+// space adds a space unless at the start of a line.
+func space(b *strings.Builder) {
+	if s := b.String(); s != "" && !strings.HasSuffix(s, "\n") && !strings.HasSuffix(s, " ") {
+		b.WriteByte(' ')
+	}
+}
+
+// tokenize splits the query into words, symbols, literals and comments.
+func tokenize(s string) (tokens []token, err error) {
+	spaced := false
+
+	add := func(text string) {
+		tokens = append(tokens, token{text: text, spaced: spaced})
+		spaced = false
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case unicode.IsSpace(rune(c)):
+			spaced = true
+			i++
+		case strings.HasPrefix(s[i:], "--"):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+
+			add(strings.TrimRight(s[i:i+end], " \t\r"))
+			i += end
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i:], "*/")
+			if end < 0 {
+				return nil, errors.New("unterminated comment")
+			}
+
+			add(s[i : i+end+2])
+			i += end + 2
+		case c == '$' && dollarTag(s[i:]) != "":
+			// Dollar-quoted strings like function bodies are kept as they are.
+			tag := dollarTag(s[i:])
+
+			end := strings.Index(s[i+len(tag):], tag)
+			if end < 0 {
+				return nil, errors.Errorf("unterminated %s", tag)
+			}
+
+			add(s[i : i+len(tag)+end+len(tag)])
+			i += len(tag) + end + len(tag)
+		case c == '\'' || c == '"':
+			end := i + 1
+
+			for ; end < len(s); end++ {
+				if s[end] != c {
+					continue
+				}
+
+				// Quotes are escaped by doubling them.
+				if end+1 < len(s) && s[end+1] == c {
+					end++
+					continue
+				}
+
+				break
+			}
+
+			if end >= len(s) {
+				return nil, errors.Errorf("unterminated %c", c)
+			}
+
+			add(s[i : end+1])
+			i = end + 1
+		case isWordChar(c):
+			end := i
+			for end < len(s) && isWordChar(s[end]) {
+				end++
+			}
+
+			add(s[i:end])
+			i = end
+		default:
+			// Operators like <=, <> or :: are kept together.
+			end := i + 1
+			for end < len(s) && strings.IndexByte("<>=!:|", s[end]) >= 0 && strings.IndexByte("<>=!:|", c) >= 0 {
+				end++
+			}
+
+			add(s[i:end])
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+// dollarTag returns the tag of the dollar-quoted string s starts with, e.g. $$ or $body$.
+func dollarTag(s string) string {
+	for end := 1; end < len(s); end++ {
+		switch c := s[end]; {
+		case c == '$':
+			return s[:end+1]
+		case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= 0x80,
+			end > 1 && '0' <= c && c <= '9':
+		default:
+			return ""
+		}
+	}
+
+	return ""
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || c == '$' || c == '@' || c == '*' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,63 @@
+package sql_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/sql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	out, err := sql.Format([]byte(`select u.id, count(*) as n from users u
+	left join orders o on o.user_id = u.id -- only with orders
+	where u.name ilike 'o''brien%' and (u.age between 18 and 30 or u.admin)
+	group by u.id order by n desc limit $1;`))
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT u.id, count(*) AS n
+FROM users u
+LEFT JOIN orders o ON o.user_id = u.id -- only with orders
+WHERE u.name ILIKE 'o''brien%'
+  AND (u.age BETWEEN 18 AND 30
+    OR u.admin)
+GROUP BY u.id
+ORDER BY n DESC
+LIMIT $1;
+`, string(out))
+
+	out, err = sql.Format([]byte("insert into users (id, name) values ($1, $2) on conflict do nothing"))
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO users (id, name)\nVALUES ($1, $2)\nON CONFLICT DO NOTHING\n", string(out))
+
+	_, err = sql.Format([]byte("select 'foo"))
+	assert.EqualError(t, err, "unterminated '")
+
+	out, err = sql.Format([]byte("select 1 -- one"))
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT 1 -- one\n", string(out))
+
+	out, err = sql.Format([]byte("with recent as (select * from orders " +
+		"where created_at > cast(now() as timestamp with time zone)) select * from recent for update"))
+	assert.NoError(t, err)
+	assert.Equal(t, `WITH recent AS (
+  SELECT *
+  FROM orders
+  WHERE created_at > cast(now() AS timestamp WITH time zone))
+SELECT *
+FROM recent FOR UPDATE
+`, string(out))
+
+	out, err = sql.Format([]byte("insert into users (id) values ($1) on conflict (id) do update set name = $2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO users (id)\nVALUES ($1)\nON CONFLICT (id) DO UPDATE\nSET name = $2\n", string(out))
+
+	out, err = sql.Format([]byte("create function f() returns int as $body$ select  1; $body$ language sql; " +
+		"select $$ from $$"))
+	assert.NoError(t, err)
+	assert.Equal(t, "create function f() returns int AS $body$ select  1; $body$ language sql;\nSELECT $$ from $$\n",
+		string(out))
+
+	_, err = sql.Format([]byte("select $$ foo"))
+	assert.EqualError(t, err, "unterminated $$")
+}
@@ -0,0 +1,293 @@
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const mergeKey = "<<"
+
+// The minimal number of entries of mappings that are hoisted into anchors.
+const minHoistedEntries = 2
+
+// ExpandAliases replaces all aliases by the values of their anchors and merge keys by the merged entries,
+// so that the result is plain data. Anchors are removed.
+var ExpandAliases EncodeOption = func(e *encoder) {
+	e.expandAliases = true
+}
+
+// HoistAnchors puts mappings with at least two entries that occur several times into anchors
+// and replaces all but the first occurrence by aliases. Occurrences with comments are kept.
+var HoistAnchors EncodeOption = func(e *encoder) {
+	e.hoistAnchors = true
+}
+
+// formatAnchors reports unused anchors and expands or hoists anchors as configured.
+func (e *encoder) formatAnchors(doc *yaml.Node) {
+	used := map[*yaml.Node]bool{}
+	anchors := []*yaml.Node{}
+
+	walk(doc, func(n *yaml.Node) {
+		if n.Anchor != "" {
+			anchors = append(anchors, n)
+		}
+
+		if n.Kind == yaml.AliasNode {
+			used[n.Alias] = true
+		}
+	})
+
+	for _, n := range anchors {
+		if !used[n] {
+			e.reportf(n.Line, n.Column, "anchor %q is never used", n.Anchor)
+		}
+	}
+
+	switch {
+	case e.expandAliases:
+		expandAliases(doc)
+		walk(doc, func(n *yaml.Node) { n.Anchor = "" })
+	case e.hoistAnchors:
+		hoistAnchors(doc)
+	}
+}
+
+// walk calls fn for the node and all its descendants, but not for the targets of aliases.
+func walk(n *yaml.Node, fn func(*yaml.Node)) {
+	fn(n)
+
+	for _, el := range n.Content {
+		walk(el, fn)
+	}
+}
+
+// expandAliases replaces all aliases in the node by copies of their anchored nodes and expands merge keys.
+func expandAliases(n *yaml.Node) {
+	for i, el := range n.Content {
+		if el.Kind == yaml.AliasNode && el.Alias != nil {
+			n.Content[i] = expandAlias(el)
+		}
+
+		expandAliases(n.Content[i])
+	}
+
+	if n.Kind == yaml.MappingNode {
+		expandMergeKeys(n)
+	}
+}
+
+// expandAlias returns a copy of the node the alias refers to, with the position and comments of the alias.
+func expandAlias(alias *yaml.Node) *yaml.Node {
+	n := deepCopy(alias.Alias)
+	n.Line, n.Column = alias.Line, alias.Column
+
+	if alias.HeadComment != "" {
+		n.HeadComment = alias.HeadComment
+	}
+
+	if alias.LineComment != "" {
+		n.LineComment = alias.LineComment
+	}
+
+	if alias.FootComment != "" {
+		n.FootComment = alias.FootComment
+	}
+
+	return n
+}
+
+func deepCopy(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+
+	for i, el := range n.Content {
+		c.Content[i] = deepCopy(el)
+	}
+
+	return &c
+}
+
+// expandMergeKeys replaces merge keys of the mapping by the entries of the merged mappings
+// that are not overridden by the mapping itself. Of several merged mappings, the first one takes precedence.
+func expandMergeKeys(n *yaml.Node) {
+	keys := map[string]bool{}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if !isMergeKey(n.Content[i]) {
+			keys[n.Content[i].Value] = true
+		}
+	}
+
+	content := make([]*yaml.Node, 0, len(n.Content))
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		if !isMergeKey(k) {
+			content = append(content, k, v)
+			continue
+		}
+
+		merged := []*yaml.Node{v}
+		if v.Kind == yaml.SequenceNode {
+			merged = v.Content
+		}
+
+		for _, m := range merged {
+			if m.Kind != yaml.MappingNode {
+				continue
+			}
+
+			for j := 0; j+1 < len(m.Content); j += 2 {
+				mk, mv := m.Content[j], m.Content[j+1]
+				if keys[mk.Value] {
+					continue
+				}
+
+				keys[mk.Value] = true
+
+				// The merged entries take the place of the merge key.
+				mk.Line, mk.Column, mv.Line = k.Line, k.Column, k.Line
+				content = append(content, mk, mv)
+			}
+		}
+	}
+
+	n.Content = content
+}
+
+func isMergeKey(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.Value == mergeKey && (n.Tag == tagMerge || n.Style == 0)
+}
+
+// hoistAnchors puts repeated mappings into anchors.
+func hoistAnchors(doc *yaml.Node) {
+	counts := map[string]int{}
+	names := map[string]bool{}
+
+	walk(doc, func(n *yaml.Node) {
+		if isHoistable(n) {
+			counts[canonical(n)]++
+		}
+
+		if n.Anchor != "" {
+			names[n.Anchor] = true
+		}
+	})
+
+	anchors := map[string]*yaml.Node{}
+	created := map[*yaml.Node]bool{}
+
+	var hoist func(n *yaml.Node, key string)
+	hoist = func(n *yaml.Node, key string) {
+		for i, el := range n.Content {
+			if n.Kind == yaml.MappingNode && i%2 == 1 {
+				key = n.Content[i-1].Value
+			}
+
+			c := ""
+			if isHoistable(el) {
+				c = canonical(el)
+			}
+
+			switch anchor, ok := anchors[c]; {
+			case c == "" || counts[c] < 2:
+			case !ok && el.Anchor == "":
+				el.Anchor = anchorName(key, names)
+				anchors[c], created[el] = el, true
+			case !ok:
+				anchors[c] = el
+			case !hasComments(el) && !hasAnchors(el):
+				// Replacing anchored nodes would drop the anchors of their aliases.
+				n.Content[i] = &yaml.Node{
+					Kind: yaml.AliasNode, Value: anchor.Anchor, Alias: anchor, Line: el.Line, Column: el.Column,
+				}
+
+				continue
+			}
+
+			hoist(el, key)
+		}
+	}
+
+	hoist(doc, "")
+
+	// Anchors of mappings whose other occurrences were inside hoisted mappings aren't needed.
+	used := map[*yaml.Node]bool{}
+	walk(doc, func(n *yaml.Node) { used[n.Alias] = true })
+
+	for n := range created {
+		if !used[n] {
+			n.Anchor = ""
+		}
+	}
+}
+
+func isHoistable(n *yaml.Node) bool {
+	return n.Kind == yaml.MappingNode && len(n.Content) >= 2*minHoistedEntries
+}
+
+// canonical returns a representation of the data of the node that is equal for equal nodes.
+func canonical(n *yaml.Node) string {
+	b := &strings.Builder{}
+
+	var write func(n *yaml.Node)
+	write = func(n *yaml.Node) {
+		if n.Kind == yaml.AliasNode {
+			fmt.Fprintf(b, "*%p", n.Alias)
+			return
+		}
+
+		fmt.Fprintf(b, "%d%s%q[", n.Kind, n.ShortTag(), n.Value)
+
+		for _, el := range n.Content {
+			write(el)
+		}
+
+		b.WriteByte(']')
+	}
+
+	write(n)
+
+	return b.String()
+}
+
+func hasComments(n *yaml.Node) (found bool) {
+	walk(n, func(n *yaml.Node) {
+		found = found || n.HeadComment != "" || n.LineComment != "" || n.FootComment != ""
+	})
+
+	return found
+}
+
+func hasAnchors(n *yaml.Node) (found bool) {
+	walk(n, func(n *yaml.Node) { found = found || n.Anchor != "" })
+
+	return found
+}
+
+// anchorName returns an unused anchor name based on the key of the anchored value.
+func anchorName(key string, names map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return -1
+		}
+	}, key)
+
+	if name == "" {
+		name = "anchor"
+	}
+
+	for i, base := 2, name; names[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+
+	names[name] = true
+
+	return name
+}
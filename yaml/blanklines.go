@@ -0,0 +1,96 @@
+package yaml
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// blankLine marks nodes that are preceded by a blank line.
+// The yaml encoder drops blank lines, so they are written as this head comment and replaced afterwards.
+const blankLine = "#format:blank-line"
+
+// markBlankLines marks all entries of mappings and sequences that are preceded by at least one blank line,
+// possibly followed by comments.
+// The first entries of blocks are never marked, so blank lines at the start of blocks are dropped.
+// This also applies to entries that were first before the keys were reordered.
+func markBlankLines(n *yaml.Node, lines []string) {
+	if n.Style&yaml.FlowStyle != 0 {
+		return
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		markEntries(n.Content, 2, lines)
+	case yaml.SequenceNode:
+		markEntries(n.Content, 1, lines)
+	}
+
+	for _, el := range n.Content {
+		markBlankLines(el, lines)
+	}
+}
+
+// markEntries marks all but the first entries, whose first nodes are the given number of nodes apart.
+func markEntries(nodes []*yaml.Node, step int, lines []string) {
+	first := -1
+
+	for i := 0; i < len(nodes); i += step {
+		if first < 0 || nodes[i].Line < first {
+			first = nodes[i].Line
+		}
+	}
+
+	for i := step; i < len(nodes); i += step {
+		if nodes[i].Line > first {
+			markBlankLine(nodes[i], lines)
+		}
+	}
+}
+
+func markBlankLine(n *yaml.Node, lines []string) {
+	if !precededByBlankLine(n.Line, lines) {
+		return
+	}
+
+	if n.HeadComment == "" {
+		n.HeadComment = blankLine
+		return
+	}
+
+	n.HeadComment = blankLine + "\n" + n.HeadComment
+}
+
+// precededByBlankLine reports whether there is a blank line before the line, skipping comments.
+func precededByBlankLine(line int, lines []string) bool {
+	for i := line - 2; i >= 0 && i < len(lines); i-- {
+		switch l := strings.TrimSpace(lines[i]); {
+		case l == "":
+			return true
+		case !strings.HasPrefix(l, "#"):
+			return false
+		}
+	}
+
+	return false
+}
+
+// restoreBlankLines replaces the marks of blank lines by blank lines.
+// Marks that follow a blank line are removed, so that there is never more than one blank line.
+func restoreBlankLines(out string) string {
+	lines := strings.Split(out, "\n")
+	res := lines[:0]
+
+	for _, l := range lines {
+		if strings.TrimSpace(l) != blankLine {
+			res = append(res, l)
+			continue
+		}
+
+		if len(res) > 0 && strings.TrimSpace(res[len(res)-1]) != "" {
+			res = append(res, "")
+		}
+	}
+
+	return strings.Join(res, "\n")
+}
@@ -0,0 +1,332 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// A Comment documents a field.
+type Comment struct {
+	// Head is written above the key of the field.
+	Head string
+	// Line is written after the value of the field if it is a scalar and after the key otherwise.
+	Line string
+}
+
+// A Commenter documents the fields of a struct when encoding, by the names of the fields in go.
+// Comments in struct tags like `comment:"..."` or `lineComment:"..."` take precedence.
+type Commenter interface {
+	YAMLComments() map[string]Comment
+}
+
+var commenterType = reflect.TypeOf((*Commenter)(nil)).Elem()
+
+// CommentDefaults writes the keys of empty fields as comments, so that examples of configurations document
+// all possible settings. The value is taken from the struct tag `default:"..."` if it is set.
+var CommentDefaults EncodeOption = func(e *encoder) {
+	e.commentDefaults = true
+}
+
+// typeHasComments reports whether values of the type could have comments.
+func typeHasComments(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == nil || seen[t] {
+		return false
+	}
+
+	seen[t] = true
+
+	if t.Implements(commenterType) || reflect.PtrTo(t).Implements(commenterType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return typeHasComments(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.Tag.Get("comment") != "" || sf.Tag.Get("lineComment") != "" || typeHasComments(sf.Type, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// comment adds the comments of the fields of the value to the encoded yaml.
+func (e *encoder) comment(v interface{}, res []byte) ([]byte, error) {
+	doc := &yamlv3.Node{}
+	if err := yamlv3.Unmarshal(res, doc); err != nil {
+		return nil, errors.Wrap(err, "parsing encoded yaml")
+	}
+
+	if len(doc.Content) == 0 {
+		return res, nil
+	}
+
+	e.addComments(reflect.ValueOf(v), doc.Content[0])
+
+	b := &bytes.Buffer{}
+	if err := yamlv3.NewEncoder(b).Encode(doc); err != nil {
+		return nil, errors.Wrap(err, "encoding comments")
+	}
+
+	return e.format(b.Bytes())
+}
+
+// addComments adds the comments of all fields of the value to the node that it was encoded to.
+func (e *encoder) addComments(rv reflect.Value, n *yamlv3.Node) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return
+		}
+
+		rv = rv.Elem()
+	}
+
+	switch {
+	case rv.Kind() == reflect.Struct && n.Kind == yamlv3.MappingNode:
+		e.commentFields(rv, n)
+	case (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && n.Kind == yamlv3.SequenceNode:
+		for i := 0; i < rv.Len() && i < len(n.Content); i++ {
+			e.addComments(rv.Index(i), n.Content[i])
+		}
+	case rv.Kind() == reflect.Map && n.Kind == yamlv3.MappingNode:
+		for _, k := range rv.MapKeys() {
+			if i := keyIndex(n, fmt.Sprint(k.Interface())); i >= 0 {
+				e.addComments(rv.MapIndex(k), n.Content[i+1])
+			}
+		}
+	}
+}
+
+// commentFields adds the comments of the fields of the struct to the keys of the mapping.
+// Empty fields are commented out if configured, together with their comments.
+func (e *encoder) commentFields(rv reflect.Value, m *yamlv3.Node) {
+	var (
+		pending []string // The comments to write above the next key.
+		last    *yamlv3.Node
+	)
+
+	for _, f := range e.commentedFields(rv) {
+		i := keyIndex(m, f.name)
+
+		if e.commentDefaults && f.v.IsZero() {
+			if i >= 0 {
+				m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			}
+
+			pending = append(pending, commentLines(f.comment.Head)...)
+			for _, l := range e.defaultLines(f, map[reflect.Type]bool{}) {
+				pending = append(pending, "# "+l)
+			}
+
+			continue
+		}
+
+		if i < 0 {
+			continue
+		}
+
+		key, val := m.Content[i], m.Content[i+1]
+		key.HeadComment = strings.Join(append(pending, commentLines(f.comment.Head)...), "\n")
+		pending, last = nil, key
+
+		if f.comment.Line != "" {
+			if val.Kind == yamlv3.ScalarNode {
+				val.LineComment = "# " + f.comment.Line
+			} else {
+				key.LineComment = "# " + f.comment.Line
+			}
+		}
+
+		e.addComments(f.v, val)
+	}
+
+	switch {
+	case len(pending) == 0:
+	case last != nil:
+		last.FootComment = strings.Join(pending, "\n")
+	default:
+		m.HeadComment = strings.Join(pending, "\n")
+	}
+}
+
+// commentedFields returns the fields of the struct with their comments.
+func (e *encoder) commentedFields(rv reflect.Value) []field {
+	comments := map[string]Comment{}
+
+	if c, ok := commenter(rv); ok {
+		comments = c.YAMLComments()
+	}
+
+	fields := e.fields(rv)
+
+	for i, f := range fields {
+		fields[i].comment = comments[f.sf.Name]
+
+		if s := f.sf.Tag.Get("comment"); s != "" {
+			fields[i].comment.Head = s
+		}
+
+		if s := f.sf.Tag.Get("lineComment"); s != "" {
+			fields[i].comment.Line = s
+		}
+	}
+
+	return fields
+}
+
+// defaultLines returns the lines of the field with its default value, before they are commented out.
+// Structs are written with the default values of all their fields unless they are already being written,
+// which are the struct types in expanding, so that recursive types end.
+func (e *encoder) defaultLines(f field, expanding map[reflect.Type]bool) []string {
+	lineComment := ""
+	if f.comment.Line != "" {
+		lineComment = " # " + f.comment.Line
+	}
+
+	t := f.sf.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	zero := reflect.New(t).Elem()
+	if _, hasDefault := f.sf.Tag.Lookup("default"); hasDefault || t.Kind() != reflect.Struct || expanding[t] ||
+		isMarshaler(zero.Interface()) || isMarshaler(zero.Addr().Interface()) {
+		return []string{f.name + ": " + defaultValue(f) + lineComment}
+	}
+
+	expanding[t] = true
+	defer delete(expanding, t)
+
+	lines := []string{f.name + ":" + lineComment}
+
+	for _, sub := range e.commentedFields(zero) {
+		for _, l := range append(commentLines(sub.comment.Head), e.defaultLines(sub, expanding)...) {
+			lines = append(lines, strings.Repeat(" ", e.indentation())+l)
+		}
+	}
+
+	return lines
+}
+
+func commenter(rv reflect.Value) (Commenter, bool) {
+	if rv.CanInterface() {
+		if c, ok := rv.Interface().(Commenter); ok {
+			return c, true
+		}
+	}
+
+	if rv.CanAddr() && rv.Addr().CanInterface() {
+		c, ok := rv.Addr().Interface().(Commenter)
+		return c, ok
+	}
+
+	// The struct was passed by value but has a pointer receiver.
+	if reflect.PtrTo(rv.Type()).Implements(commenterType) {
+		p := reflect.New(rv.Type())
+		p.Elem().Set(rv)
+
+		return p.Interface().(Commenter), true //nolint:forcetypeassert // checked above
+	}
+
+	return nil, false
+}
+
+func commentLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight("# "+l, " ")
+	}
+
+	return lines
+}
+
+// defaultValue returns the default value of the field as given in its struct tag or the encoded empty value.
+func defaultValue(f field) string {
+	if s, ok := f.sf.Tag.Lookup("default"); ok {
+		return s
+	}
+
+	res, err := yamlv3.Marshal(reflect.Zero(f.sf.Type).Interface())
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(res))
+}
+
+func keyIndex(m *yamlv3.Node, key string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// A field is a field of a struct and the key it is encoded as.
+type field struct {
+	name    string
+	sf      reflect.StructField
+	v       reflect.Value
+	comment Comment
+}
+
+// fields returns the encoded fields of the struct in order, including those of squashed or inlined structs.
+func (e *encoder) fields(rv reflect.Value) (fields []field) {
+	tp := rv.Type()
+
+	for i := 0; i < tp.NumField(); i++ {
+		sf := tp.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, inline := e.keyName(sf)
+
+		switch fv := reflect.Indirect(rv.Field(i)); {
+		case name == "-":
+		case inline && fv.Kind() == reflect.Struct:
+			fields = append(fields, e.fields(fv)...)
+		case inline:
+		default:
+			fields = append(fields, field{name: name, sf: sf, v: rv.Field(i)})
+		}
+	}
+
+	return fields
+}
+
+// keyName returns the key of the field and whether its fields are encoded in the surrounding mapping.
+func (e *encoder) keyName(sf reflect.StructField) (string, bool) {
+	if e.useMapstructure {
+		tag := parseMapstructureTag(sf)
+		return tag.name, tag.squash || tag.remain
+	}
+
+	opts := strings.Split(sf.Tag.Get("yaml"), ",")
+	for _, opt := range opts[1:] {
+		if opt == "inline" {
+			return "", true
+		}
+	}
+
+	if opts[0] != "" {
+		return opts[0], false
+	}
+
+	return strings.ToLower(sf.Name), false
+}
@@ -0,0 +1,360 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// An Error is a problem with a yaml document at a certain position.
+type Error struct {
+	Filename     string
+	Line, Column int
+	Message      string
+}
+
+// Error returns the error in the form "file:line:column: message".
+func (e *Error) Error() string {
+	if e.Filename == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s", e.Filename, e.Line, e.Column, e.Message)
+}
+
+// Errors are all problems found while decoding a document, in order of their positions.
+type Errors []*Error
+
+// Error returns all errors on separate lines.
+func (errs Errors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+// A Decoder decodes yaml documents from a stream and checks them against the values they are decoded into.
+// Duplicate keys are always errors, the first value of a key is decoded.
+type Decoder struct {
+	dec *yamlv3.Decoder
+
+	filename      string
+	knownFields   bool
+	requireFields bool
+}
+
+// DecodeOption is an option to decode yaml in a certain way.
+type DecodeOption func(d *Decoder)
+
+// DisallowUnknownFields reports keys that don't match any field of the struct they are decoded into.
+var DisallowUnknownFields DecodeOption = func(d *Decoder) {
+	d.knownFields = true
+}
+
+// CheckRequiredFields reports fields with the struct tag `required:"true"` whose keys are missing.
+var CheckRequiredFields DecodeOption = func(d *Decoder) {
+	d.requireFields = true
+}
+
+// WithFilename sets the name of the file that is reported in errors.
+func WithFilename(name string) DecodeOption {
+	return func(d *Decoder) {
+		d.filename = name
+	}
+}
+
+// NewDecoder returns a decoder that reads from r.
+func NewDecoder(r io.Reader, opts ...DecodeOption) *Decoder {
+	d := &Decoder{dec: yamlv3.NewDecoder(r)}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+// UnmarshalStrict decodes the document into v and reports unknown and missing required fields
+// as well as duplicate keys. All problems are returned together as Errors.
+func UnmarshalStrict(src []byte, v interface{}, opts ...DecodeOption) error {
+	d := NewDecoder(bytes.NewReader(src), append([]DecodeOption{DisallowUnknownFields, CheckRequiredFields}, opts...)...)
+
+	err := d.Decode(v)
+	if !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return d.checkEmpty(1, v)
+}
+
+// Decode decodes the next document into v. It returns io.EOF if there are no more documents.
+// All problems of the document are returned together as Errors.
+func (d *Decoder) Decode(v interface{}) error {
+	doc := &yamlv3.Node{}
+	if err := d.dec.Decode(doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+
+		return d.parseError(err)
+	}
+
+	if len(doc.Content) == 0 {
+		return d.checkEmpty(doc.Line, v)
+	}
+
+	root := doc.Content[0]
+	if root.Kind == yamlv3.ScalarNode && root.ShortTag() == "!!null" {
+		// Empty documents only consist of a null value and have no keys at all.
+		root = &yamlv3.Node{Kind: yamlv3.MappingNode, Line: doc.Line, Column: 1}
+	}
+
+	errs := d.check(root, reflect.TypeOf(v))
+
+	if err := doc.Decode(v); err != nil {
+		var typeErr *yamlv3.TypeError
+		if !errors.As(err, &typeErr) {
+			return errors.Wrap(err, "decoding")
+		}
+
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, d.typeError(doc, msg))
+		}
+	}
+
+	return errs.sorted()
+}
+
+// checkEmpty reports the missing required fields of v for an empty document at the line.
+func (d *Decoder) checkEmpty(line int, v interface{}) error {
+	if line < 1 {
+		line = 1
+	}
+
+	return d.check(&yamlv3.Node{Kind: yamlv3.MappingNode, Line: line, Column: 1}, reflect.TypeOf(v)).sorted()
+}
+
+var rxLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// parseError returns the error of the yaml parser with its position.
+// The parser only reports lines, so the errors are at the start of the line.
+func (d *Decoder) parseError(err error) error {
+	m := rxLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return errors.Wrap(err, "parsing")
+	}
+
+	line, _ := strconv.Atoi(m[1])
+
+	return Errors{{Filename: d.filename, Line: line, Column: 1, Message: m[2]}}
+}
+
+// typeError returns an error of the yaml decoder like "line 3: cannot unmarshal ..." with the column
+// of the last scalar on the line, which is usually the value that couldn't be decoded.
+func (d *Decoder) typeError(doc *yamlv3.Node, msg string) *Error {
+	m := rxLine.FindStringSubmatch(msg)
+	if m == nil {
+		return &Error{Filename: d.filename, Message: msg}
+	}
+
+	line, _ := strconv.Atoi(m[1])
+	e := &Error{Filename: d.filename, Line: line, Column: 1, Message: m[2]}
+
+	walk(doc, func(n *yamlv3.Node) {
+		if n.Line == line && n.Kind == yamlv3.ScalarNode && n.Column > e.Column {
+			e.Column = n.Column
+		}
+	})
+
+	return e
+}
+
+// check reports duplicate keys and, if configured, unknown and missing required fields.
+func (d *Decoder) check(n *yamlv3.Node, t reflect.Type) (errs Errors) {
+	d.checkNode(n, t, func(n *yamlv3.Node, format string, args ...interface{}) {
+		errs = append(errs, &Error{
+			Filename: d.filename, Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...),
+		})
+	})
+
+	return errs
+}
+
+func (errs Errors) sorted() error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+
+		return errs[i].Column < errs[j].Column
+	})
+
+	return errs
+}
+
+type reportFunc func(n *yamlv3.Node, format string, args ...interface{})
+
+var (
+	unmarshalerType     = reflect.TypeOf((*yamlv3.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func (d *Decoder) checkNode(n *yamlv3.Node, t reflect.Type, report reportFunc) {
+	if n.Kind == yamlv3.MappingNode {
+		checkDuplicateKeys(n, report)
+	}
+
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil || reflect.PtrTo(t).Implements(unmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		// Check the rest of the document for duplicate keys.
+		for _, el := range n.Content {
+			d.checkNode(el, nil, report)
+		}
+
+		return
+	}
+
+	switch {
+	case n.Kind == yamlv3.MappingNode && t.Kind() == reflect.Struct:
+		d.checkStruct(n, t, report)
+	case n.Kind == yamlv3.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			d.checkNode(n.Content[i+1], t.Elem(), report)
+		}
+	case n.Kind == yamlv3.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for _, el := range n.Content {
+			d.checkNode(el, t.Elem(), report)
+		}
+	default:
+		for _, el := range n.Content {
+			d.checkNode(el, nil, report)
+		}
+	}
+}
+
+// checkDuplicateKeys reports duplicate keys of the mapping and removes them,
+// so that the decoder doesn't stop at them and all other problems can be reported.
+func checkDuplicateKeys(n *yamlv3.Node, report reportFunc) {
+	seen := map[string]*yamlv3.Node{}
+	content := n.Content[:0]
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k := n.Content[i]
+
+		if first, ok := seen[k.Value]; ok && k.Kind == yamlv3.ScalarNode && k.Value != mergeKey {
+			report(k, "duplicate key %q, first defined at line %d", k.Value, first.Line)
+			continue
+		}
+
+		seen[k.Value] = k
+		content = append(content, k, n.Content[i+1])
+	}
+
+	n.Content = content
+}
+
+func (d *Decoder) checkStruct(n *yamlv3.Node, t reflect.Type, report reportFunc) {
+	fields, inlineMap := decodedFields(t)
+
+	present := map[string]bool{}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		present[k.Value] = true
+
+		f, ok := fields[k.Value]
+
+		switch {
+		case ok:
+			d.checkNode(v, f.Type, report)
+		case inlineMap != nil:
+			d.checkNode(v, inlineMap.Elem(), report)
+		case d.knownFields && k.Value != mergeKey:
+			report(k, "unknown field %q", k.Value)
+		default:
+			d.checkNode(v, nil, report)
+		}
+	}
+
+	if !d.requireFields {
+		return
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool { return fields[names[i]].Index[0] < fields[names[j]].Index[0] })
+
+	for _, name := range names {
+		if !present[name] && fields[name].Tag.Get("required") == "true" {
+			report(n, "missing required field %q", name)
+		}
+	}
+}
+
+// decodedFields returns the fields of the struct by their keys, including those of inlined structs,
+// and the type of an inlined map that takes all other keys.
+func decodedFields(t reflect.Type) (fields map[string]reflect.StructField, inlineMap reflect.Type) {
+	fields = map[string]reflect.StructField{}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		opts := strings.Split(sf.Tag.Get("yaml"), ",")
+		name, inline := opts[0], false
+
+		for _, opt := range opts[1:] {
+			inline = inline || opt == "inline"
+		}
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		switch {
+		case name == "-":
+		case inline && ft.Kind() == reflect.Map:
+			inlineMap = ft
+		case inline && ft.Kind() == reflect.Struct:
+			inner, innerMap := decodedFields(ft)
+			for k, f := range inner {
+				f.Index = append([]int{i}, f.Index...)
+				fields[k] = f
+			}
+
+			if innerMap != nil {
+				inlineMap = innerMap
+			}
+		case name == "":
+			fields[strings.ToLower(sf.Name)] = sf
+		default:
+			fields[name] = sf
+		}
+	}
+
+	return fields, inlineMap
+}
@@ -0,0 +1,85 @@
+package yaml_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/faetools/format/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type (
+	strictDatabase struct {
+		Host string `yaml:"host" required:"true"`
+		Port int    `yaml:"port"`
+	}
+
+	strictConfig struct {
+		Name      string           `yaml:"name" required:"true"`
+		Databases []strictDatabase `yaml:"databases"`
+		Labels    map[string]string
+		Extra     struct {
+			Debug bool `yaml:"debug"`
+		} `yaml:",inline"`
+	}
+)
+
+func TestUnmarshalStrict(t *testing.T) {
+	t.Parallel()
+
+	c := &strictConfig{}
+	require.NoError(t, yaml.UnmarshalStrict([]byte("name: foo\ndebug: true\nlabels: {a: b}\n"), c))
+	assert.Equal(t, "foo", c.Name)
+	assert.True(t, c.Extra.Debug)
+
+	err := yaml.UnmarshalStrict([]byte(`name: foo
+name: bar
+databases:
+  - host: localhost
+    port: abc
+  - port: 5432
+    user: root
+unknown: 1
+`), &strictConfig{}, yaml.WithFilename("config.yaml"))
+	require.EqualError(t, err, `config.yaml:2:1: duplicate key "name", first defined at line 1
+config.yaml:5:11: cannot unmarshal !!str `+"`abc`"+` into int
+config.yaml:6:5: missing required field "host"
+config.yaml:7:5: unknown field "user"
+config.yaml:8:1: unknown field "unknown"`)
+
+	var errs yaml.Errors
+	require.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 5)
+
+	err = yaml.UnmarshalStrict(nil, &strictConfig{})
+	require.EqualError(t, err, `1:1: missing required field "name"`)
+
+	err = yaml.UnmarshalStrict([]byte("name: [\n"), &strictConfig{})
+	require.EqualError(t, err, `1:1: did not find expected node content`)
+}
+
+func TestDecoder(t *testing.T) {
+	t.Parallel()
+
+	d := yaml.NewDecoder(strings.NewReader("host: a\n---\nhost: b\nuser: c\n---\nport: 1\n"), yaml.DisallowUnknownFields)
+
+	db := &strictDatabase{}
+	require.NoError(t, d.Decode(db))
+	assert.Equal(t, "a", db.Host)
+
+	require.EqualError(t, d.Decode(&strictDatabase{}), `4:1: unknown field "user"`)
+
+	// Required fields are only checked if configured.
+	require.NoError(t, d.Decode(&strictDatabase{}))
+
+	require.True(t, errors.Is(d.Decode(&strictDatabase{}), io.EOF))
+
+	// Empty documents have no keys at all.
+	d = yaml.NewDecoder(strings.NewReader("host: a\n---\n# no keys\n---\nhost: b\n"), yaml.CheckRequiredFields)
+	require.NoError(t, d.Decode(&strictDatabase{}))
+	require.EqualError(t, d.Decode(&strictDatabase{}), `2:1: missing required field "host"`)
+	require.NoError(t, d.Decode(&strictDatabase{}))
+}
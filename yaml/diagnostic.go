@@ -0,0 +1,26 @@
+package yaml
+
+import "fmt"
+
+// A Diagnostic is a problem found while formatting.
+type Diagnostic struct {
+	Line, Column int
+	Message      string
+}
+
+// String returns the diagnostic in the form "line:column: message".
+func (d Diagnostic) String() string { return fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message) }
+
+// WithDiagnostics reports any problems found while formatting to the given function, e.g. unused anchors.
+// Positions refer to the source.
+func WithDiagnostics(report func(Diagnostic)) EncodeOption {
+	return func(e *encoder) {
+		e.report = report
+	}
+}
+
+func (e *encoder) reportf(line, column int, format string, args ...interface{}) {
+	if e.report != nil {
+		e.report(Diagnostic{Line: line, Column: column, Message: fmt.Sprintf(format, args...)})
+	}
+}
@@ -0,0 +1,401 @@
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+)
+
+// Set sets the value at path in every document of src and returns the result.
+//
+// The path uses the syntax of KeyOrder, e.g. $.jobs.build.steps[0].uses; `*` and `[*]` match all keys and
+// entries and negative indices count from the end of a sequence. Missing keys are created. The value may be a
+// *yaml.Node, which is copied for every match.
+//
+// Only the changed lines are formatted, the rest of src is kept as it is. If the lines around the change
+// aren't formatted, the whole document is formatted instead.
+func Set(src []byte, path string, value interface{}, opts ...EncodeOption) ([]byte, error) {
+	return editPath(src, path, true, opts, func(parent *yaml.Node, elem string) error {
+		n, err := valueNode(value)
+		if err != nil {
+			return err
+		}
+
+		return setNode(parent, elem, n)
+	})
+}
+
+// Delete removes the value at path from every document of src and returns the result, see Set.
+func Delete(src []byte, path string, opts ...EncodeOption) ([]byte, error) {
+	deleted := false
+
+	res, err := editPath(src, path, false, opts, func(parent *yaml.Node, elem string) error {
+		if deleteNode(parent, elem) {
+			deleted = true
+		}
+
+		return nil
+	})
+	if err == nil && !deleted {
+		return nil, errors.Errorf("path %s not found", path)
+	}
+
+	return res, err
+}
+
+// Append appends value to the sequence at path in every document of src and returns the result, see Set.
+//
+// A missing sequence is created.
+func Append(src []byte, path string, value interface{}, opts ...EncodeOption) ([]byte, error) {
+	return editPath(src, path, true, opts, func(parent *yaml.Node, elem string) error {
+		n, err := valueNode(value)
+		if err != nil {
+			return err
+		}
+
+		seq := childNode(parent, elem)
+		if seq == nil {
+			return setNode(parent, elem, &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{n}})
+		}
+
+		if seq.Kind != yaml.SequenceNode {
+			return errorf(seq, "%s is not a sequence", elem)
+		}
+
+		seq.Content = append(seq.Content, n)
+
+		return nil
+	})
+}
+
+// editPath calls fn with the parents of path and the last element of path in every document of src.
+func editPath(src []byte, path string, create bool, opts []EncodeOption, fn func(parent *yaml.Node, elem string) error) ([]byte, error) {
+	elems := splitPath(path)
+	if len(elems) == 0 {
+		return nil, errors.Errorf("path %q has no elements", path)
+	}
+
+	found := false
+
+	e := newEncoder(nil)
+	e.apply(opts)
+
+	res, err := e.edit(src, func(doc *yaml.Node) error {
+		if len(doc.Content) == 0 {
+			if !create {
+				return nil
+			}
+
+			doc.Content = []*yaml.Node{newCollection(elems[0])}
+		}
+
+		return walkPath(doc.Content[0], elems, create, func(parent *yaml.Node, elem string) error {
+			found = true
+
+			return fn(parent, elem)
+		})
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "editing %s", path)
+	}
+
+	if !found {
+		return nil, errors.Errorf("path %s not found", path)
+	}
+
+	formatted, err := e.format(src)
+	if err != nil {
+		return nil, err
+	}
+
+	return splice(src, formatted, res), nil
+}
+
+// splice applies the changes from formatted to edited to src, which formats to formatted.
+// If a changed line of formatted isn't in src, e.g. because it wasn't formatted, edited is returned.
+func splice(src, formatted, edited []byte) []byte {
+	a := strings.SplitAfter(string(src), "\n")
+	f := strings.SplitAfter(string(formatted), "\n")
+	e := strings.SplitAfter(string(edited), "\n")
+
+	// The lines of src that the lines of formatted are equal to.
+	inSrc := make([]int, len(f))
+	for i := range inSrc {
+		inSrc[i] = -1
+	}
+
+	for _, op := range difflib.NewMatcher(a, f).GetOpCodes() {
+		if op.Tag == 'e' {
+			for k := 0; k < op.J2-op.J1; k++ {
+				inSrc[op.J1+k] = op.I1 + k
+			}
+		}
+	}
+
+	b := &strings.Builder{}
+	last := 0 // The next line of src to write.
+
+	for _, op := range difflib.NewMatcher(f, e).GetOpCodes() {
+		if op.Tag == 'e' {
+			continue
+		}
+
+		start, end, ok := srcRange(inSrc, op.I1, op.I2)
+		if !ok || start < last {
+			return edited
+		}
+
+		b.WriteString(strings.Join(a[last:start], ""))
+		b.WriteString(strings.Join(e[op.J1:op.J2], ""))
+
+		last = end
+	}
+
+	b.WriteString(strings.Join(a[last:], ""))
+
+	return []byte(b.String())
+}
+
+// srcRange returns the lines of src that are equal to the lines from i1 to i2 of formatted.
+// For insertions, i.e. i1 == i2, the range is empty and between the equal neighbours.
+func srcRange(inSrc []int, i1, i2 int) (start, end int, ok bool) {
+	if i1 == i2 {
+		switch {
+		case i1 > 0 && inSrc[i1-1] < 0, i1 < len(inSrc) && inSrc[i1] < 0:
+			return 0, 0, false
+		case i1 > 0:
+			start = inSrc[i1-1] + 1
+		}
+
+		return start, start, i1 == len(inSrc) || inSrc[i1] == start
+	}
+
+	for i := i1; i < i2; i++ {
+		if inSrc[i] != inSrc[i1]+i-i1 || inSrc[i] < 0 {
+			return 0, 0, false
+		}
+	}
+
+	return inSrc[i1], inSrc[i2-1] + 1, true
+}
+
+// walkPath calls fn with all nodes matching the parent of path, creating missing mapping keys if create is set.
+func walkPath(n *yaml.Node, path []string, create bool, fn func(parent *yaml.Node, elem string) error) error {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	elem := path[0]
+
+	if len(path) == 1 {
+		return walkLast(n, elem, fn)
+	}
+
+	switch {
+	case elem == "*" && n.Kind == yaml.MappingNode:
+		for i := 1; i < len(n.Content); i += 2 {
+			if err := walkPath(n.Content[i], path[1:], create, fn); err != nil {
+				return err
+			}
+		}
+	case elem == "[*]" && n.Kind == yaml.SequenceNode:
+		for _, el := range n.Content {
+			if err := walkPath(el, path[1:], create, fn); err != nil {
+				return err
+			}
+		}
+	default:
+		child := childNode(n, elem)
+		if child == nil {
+			if !create || n.Kind != yaml.MappingNode || isIndex(elem) {
+				return nil
+			}
+
+			child = newCollection(path[1])
+			n.Content = append(n.Content, newKey(elem), child)
+		}
+
+		return walkPath(child, path[1:], create, fn)
+	}
+
+	return nil
+}
+
+// walkLast calls fn with n and elem or, if elem is a wildcard, all keys or indices of n from last to first.
+func walkLast(n *yaml.Node, elem string, fn func(parent *yaml.Node, elem string) error) error {
+	var elems []string
+
+	switch {
+	case elem == "*" && n.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			elems = append(elems, n.Content[i].Value)
+		}
+	case elem == "[*]" && n.Kind == yaml.SequenceNode:
+		for i := range n.Content {
+			elems = append(elems, "["+strconv.Itoa(i)+"]")
+		}
+	default:
+		return fn(n, elem)
+	}
+
+	// Going backwards keeps the remaining indices valid when entries are removed.
+	for i := len(elems) - 1; i >= 0; i-- {
+		if err := fn(n, elems[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// childNode returns the value of the key or index elem in n or nil if it does not exist.
+func childNode(n *yaml.Node, elem string) *yaml.Node {
+	switch n.Kind {
+	case yaml.MappingNode:
+		if i := keyIndex(n, elem); i >= 0 {
+			return n.Content[i+1]
+		}
+	case yaml.SequenceNode:
+		if i, ok := sequenceIndex(n, elem); ok {
+			return n.Content[i]
+		}
+	}
+
+	return nil
+}
+
+func setNode(parent *yaml.Node, elem string, value *yaml.Node) error {
+	switch {
+	case parent.Kind == yaml.MappingNode && !isIndex(elem):
+		i := keyIndex(parent, elem)
+		if i < 0 {
+			parent.Content = append(parent.Content, newKey(elem), value)
+
+			return nil
+		}
+
+		keepMetadata(value, parent.Content[i+1])
+		parent.Content[i+1] = value
+	case parent.Kind == yaml.SequenceNode:
+		i, ok := sequenceIndex(parent, elem)
+		if !ok {
+			return errorf(parent, "index %s out of range", elem)
+		}
+
+		keepMetadata(value, parent.Content[i])
+		parent.Content[i] = value
+	default:
+		return errorf(parent, "cannot set %s in %s", elem, kindName(parent))
+	}
+
+	return nil
+}
+
+// deleteNode removes elem from parent and reports whether it existed.
+func deleteNode(parent *yaml.Node, elem string) bool {
+	switch parent.Kind {
+	case yaml.MappingNode:
+		if i := keyIndex(parent, elem); i >= 0 {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+
+			return true
+		}
+	case yaml.SequenceNode:
+		if i, ok := sequenceIndex(parent, elem); ok {
+			parent.Content = append(parent.Content[:i], parent.Content[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// valueNode encodes value into a new node.
+func valueNode(value interface{}) (*yaml.Node, error) {
+	if n, ok := value.(*yaml.Node); ok {
+		// Every match gets its own copy to keep its own comments.
+		return deepCopy(n), nil
+	}
+
+	n := &yaml.Node{}
+	if err := n.Encode(value); err != nil {
+		return nil, errors.Wrap(err, "encoding value")
+	}
+
+	return n, nil
+}
+
+// keepMetadata copies the anchor and comments of the replaced node old to n unless n has its own.
+func keepMetadata(n, old *yaml.Node) {
+	if n.Anchor == "" {
+		n.Anchor = old.Anchor
+	}
+
+	if n.HeadComment == "" {
+		n.HeadComment = old.HeadComment
+	}
+
+	if n.LineComment == "" {
+		n.LineComment = old.LineComment
+	}
+
+	if n.FootComment == "" {
+		n.FootComment = old.FootComment
+	}
+}
+
+// sequenceIndex resolves the index elem, e.g. [0] or [-1], in n.
+func sequenceIndex(n *yaml.Node, elem string) (int, bool) {
+	if !isIndex(elem) {
+		return 0, false
+	}
+
+	i, err := strconv.Atoi(elem[1 : len(elem)-1])
+	if err != nil {
+		return 0, false
+	}
+
+	if i < 0 {
+		i += len(n.Content)
+	}
+
+	return i, i >= 0 && i < len(n.Content)
+}
+
+func isIndex(elem string) bool {
+	return strings.HasPrefix(elem, "[") && strings.HasSuffix(elem, "]")
+}
+
+func newKey(key string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+}
+
+// newCollection returns an empty collection that can hold elem.
+func newCollection(elem string) *yaml.Node {
+	if isIndex(elem) {
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+func kindName(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "sequence"
+	default:
+		return "scalar"
+	}
+}
+
+// errorf returns an error positioned at n.
+func errorf(n *yaml.Node, format string, args ...interface{}) error {
+	return &Error{Line: n.Line, Column: n.Column, Message: fmt.Sprintf(format, args...)}
+}
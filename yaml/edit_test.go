@@ -0,0 +1,138 @@
+package yaml_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/yaml"
+	"github.com/stretchr/testify/require"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const info = `# the service
+name: format
+version: 0.0.10 # bumped by the bot
+
+library:
+  subType: Other
+devToolVersion: 0.0.16
+`
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name, in, path string
+		value          interface{}
+		out            string
+	}{
+		{"version", info, "$.version", "0.0.11", `# the service
+name: format
+version: 0.0.11 # bumped by the bot
+
+library:
+  subType: Other
+devToolVersion: 0.0.16
+`},
+		{"nested", info, "$.library.subType", "Tool", `# the service
+name: format
+version: 0.0.10 # bumped by the bot
+
+library:
+  subType: Tool
+devToolVersion: 0.0.16
+`},
+		{"new key", "a: 1\n", "$.b.c", true, "a: 1\nb:\n  c: true\n"},
+		{"index", "a:\n  - 1\n  - 2\n", "$.a[-1]", 3, "a:\n  - 1\n  - 3\n"},
+		{"wildcard", "a:\n  - b: 1\n  - b: 2\n", "$.a[*].b", 0, "a:\n  - b: 0\n  - b: 0\n"},
+		{"mapping", "a: 1\n", "$.a", map[string]int{"b": 2}, "a:\n  b: 2\n"},
+		{"empty", "", "$.a", "1", "a: '1'\n"},
+		{"comment only", "# comment\n", "$.a", 1, "# comment\na: 1\n"},
+		{"all documents", "a: 1\n---\na: 2\n", "$.a", 3, "a: 3\n---\na: 3\n"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			out, err := yaml.Set([]byte(tt.in), tt.path, tt.value)
+			require.NoError(t, err)
+			require.Equal(t, tt.out, string(out))
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	out, err := yaml.Delete([]byte(info), "$.library")
+	require.NoError(t, err)
+	require.Equal(t, `# the service
+name: format
+version: 0.0.10 # bumped by the bot
+devToolVersion: 0.0.16
+`, string(out))
+
+	out, err = yaml.Delete([]byte("a:\n  - 1\n  - 2\n  - 3\n"), "$.a[1]")
+	require.NoError(t, err)
+	require.Equal(t, "a:\n  - 1\n  - 3\n", string(out))
+
+	out, err = yaml.Delete([]byte("a:\n  b: 1\n  c: 2\n"), "$.a.*")
+	require.NoError(t, err)
+	require.Equal(t, "a: {}\n", string(out))
+
+	_, err = yaml.Delete([]byte(info), "$.missing")
+	require.EqualError(t, err, "path $.missing not found")
+}
+
+func TestAppend(t *testing.T) {
+	t.Parallel()
+
+	out, err := yaml.Append([]byte("# steps\nsteps:\n  - run: make\n"), "$.steps", map[string]string{"run": "make test"})
+	require.NoError(t, err)
+	require.Equal(t, "# steps\nsteps:\n  - run: make\n  - run: make test\n", string(out))
+
+	out, err = yaml.Append([]byte("a: 1\n"), "$.b", "c")
+	require.NoError(t, err)
+	require.Equal(t, "a: 1\nb:\n  - c\n", string(out))
+
+	_, err = yaml.Append([]byte("a: 1\n"), "$.a", "c")
+	require.EqualError(t, err, "editing $.a: 1:4: a is not a sequence")
+}
+
+func TestEdit_Options(t *testing.T) {
+	t.Parallel()
+
+	out, err := yaml.Set([]byte("a: 1\n"), "$.b.c", 3, yaml.Indent(4))
+	require.NoError(t, err)
+	require.Equal(t, "a: 1\nb:\n    c: 3\n", string(out))
+}
+
+func TestEdit_MinimalChange(t *testing.T) {
+	t.Parallel()
+
+	// Unformatted lines are kept unless they change.
+	src := []byte("a:   \"1\"\nb: 2 # two\nc:\n    - x\n")
+
+	out, err := yaml.Set(src, "$.b", 3)
+	require.NoError(t, err)
+	require.Equal(t, "a:   \"1\"\nb: 3 # two\nc:\n    - x\n", string(out))
+
+	out, err = yaml.Delete(src, "$.b")
+	require.NoError(t, err)
+	require.Equal(t, "a:   \"1\"\nc:\n    - x\n", string(out))
+
+	// The changed lines aren't in src, so the document is formatted.
+	out, err = yaml.Append(src, "$.c", "y")
+	require.NoError(t, err)
+	require.Equal(t, "a: '1'\nb: 2 # two\nc:\n  - x\n  - y\n", string(out))
+}
+
+func TestSet_NodePerMatch(t *testing.T) {
+	t.Parallel()
+
+	n := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!int", Value: "0"}
+
+	out, err := yaml.Set([]byte("a:\n  - b: 1 # one\n  - b: 2 # two\n"), "$.a[*].b", n)
+	require.NoError(t, err)
+	require.Equal(t, "a:\n  - b: 0 # one\n  - b: 0 # two\n", string(out))
+	require.Empty(t, n.LineComment)
+}
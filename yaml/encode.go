@@ -2,17 +2,27 @@ package yaml
 
 import (
 	"bytes"
+	"encoding"
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/goccy/go-yaml"
 	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 const generatedNotice = "# Code generated by %s; DO NOT EDIT.\n"
 
+// The supported number of spaces to indent with.
+const (
+	defaultIndent = 2
+	minIndent     = 2
+	maxIndent     = 9
+)
+
 // Encode encodes any interface with the given options.
 // The output is already formatted.
 func Encode(v interface{}, opts ...EncodeOption) ([]byte, error) {
@@ -30,14 +40,54 @@ type encoder struct {
 	notice          string
 	useMapstructure bool
 	useYAMLV3       bool
+
+	indent         int
+	flushSequences bool
+
+	sortKeys  bool
+	keyOrders []keyOrder
+
+	quoting      QuotingPolicy
+	doubleQuotes bool
+	quoteKeys    bool
+
+	expandAliases bool
+	hoistAnchors  bool
+	report        func(Diagnostic)
+
+	commentDefaults bool
 }
 
 func newEncoder(w io.Writer) *encoder { return &encoder{w: w} }
 
-func (e *encoder) encode(v interface{}, opts ...EncodeOption) error {
+func (e *encoder) apply(opts []EncodeOption) {
 	for _, opt := range opts {
 		opt(e)
 	}
+}
+
+// indentation returns the number of spaces to indent with, 2 by default.
+func (e *encoder) indentation() int {
+	if e.indent < minIndent || e.indent > maxIndent {
+		return defaultIndent
+	}
+
+	return e.indent
+}
+
+func (e *encoder) encode(v interface{}, opts ...EncodeOption) error {
+	e.apply(opts)
+
+	res, err := e.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if e.commentDefaults || typeHasComments(reflect.TypeOf(v), map[reflect.Type]bool{}) {
+		if res, err = e.comment(v, res); err != nil {
+			return err
+		}
+	}
 
 	if e.notice != "" {
 		if _, err := e.w.Write([]byte(e.notice)); err != nil {
@@ -45,44 +95,92 @@ func (e *encoder) encode(v interface{}, opts ...EncodeOption) error {
 		}
 	}
 
+	_, err = e.w.Write(res)
+
+	return err
+}
+
+func (e *encoder) marshal(v interface{}) ([]byte, error) {
 	if e.useYAMLV3 {
-		return e.encodeV3(v)
+		res, err := yamlv3.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return e.format(res)
 	}
 
 	if e.useMapstructure {
 		v = e.transformToMap(v)
 	}
 
-	n, err := yaml.ValueToNode(v,
-		yaml.UseLiteralStyleIfMultiline(true),
-		yaml.IndentSequence(true),
-		yaml.UseSingleQuote(true))
+	n, err := yaml.ValueToNode(v, e.encodeOptions()...)
 	if err != nil {
-		return errors.Wrap(err, "transforming value to node")
+		return nil, errors.Wrap(err, "transforming value to node")
 	}
 
-	return errors.Wrap(yaml.NewEncoder(e.w).Encode(n), "encoding node")
-}
+	e.quoteYAML11(n)
 
-func (e *encoder) encodeV3(v interface{}) error {
-	res, err := Marshal(v)
-	if err != nil {
-		return err
+	b := &bytes.Buffer{}
+	if err := yaml.NewEncoder(b).Encode(n); err != nil {
+		return nil, errors.Wrap(err, "encoding node")
 	}
 
-	_, err = e.w.Write(res)
-	return err
+	return b.Bytes(), nil
 }
 
+// transformToMap transforms the value into maps and slices as github.com/mitchellh/mapstructure would at every depth.
+// Structs become mappings with the keys in the order of their fields and the keys of maps are sorted.
+// Empty values are omitted, since decoding leaves them empty anyway.
 func (e *encoder) transformToMap(v interface{}) interface{} {
-	rv := reflect.Indirect(reflect.ValueOf(v))
+	return e.transform(reflect.ValueOf(v))
+}
+
+func (e *encoder) transform(rv reflect.Value) interface{} {
+	if !rv.IsValid() {
+		return nil
+	}
+
+	if rv.CanInterface() && isMarshaler(rv.Interface()) {
+		return rv.Interface()
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+
+		return e.transform(rv.Elem())
+	case reflect.Struct:
+		m := yaml.MapSlice{}
+		e.appendFields(&m, rv)
+
+		return m
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() || rv.Type().Elem().Kind() == reflect.Uint8 {
+			return rv.Interface()
+		}
+
+		s := make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = e.transform(rv.Index(i))
+		}
 
-	if rv.Kind() != reflect.Struct {
-		return v
+		return s
+	case reflect.Map:
+		m := yaml.MapSlice{}
+		e.appendEntries(&m, rv)
+
+		return m
+	default:
+		return rv.Interface()
 	}
+}
 
+// appendFields appends the fields of the struct to the mapping.
+func (e *encoder) appendFields(m *yaml.MapSlice, rv reflect.Value) {
 	tp := rv.Type()
-	m := map[string]interface{}{}
 
 	for i, l := 0, tp.NumField(); i < l; i++ {
 		sf := tp.Field(i)
@@ -90,24 +188,82 @@ func (e *encoder) transformToMap(v interface{}) interface{} {
 			continue
 		}
 
-		fv := e.transformToMap(rv.Field(i).Interface())
-
-		if isEmptyValue(reflect.ValueOf(fv)) {
+		tag := parseMapstructureTag(sf)
+		if tag.name == "-" {
 			continue
 		}
 
-		m[fieldName(sf)] = fv
+		fv := reflect.Indirect(rv.Field(i))
+
+		switch {
+		case tag.squash && fv.Kind() == reflect.Struct:
+			e.appendFields(m, fv)
+		case tag.remain && fv.Kind() == reflect.Map:
+			e.appendEntries(m, fv)
+		default:
+			e.appendValue(m, tag.name, e.transform(rv.Field(i)))
+		}
 	}
+}
 
-	return m
+// appendEntries appends the entries of the map to the mapping, sorted by their keys.
+func (e *encoder) appendEntries(m *yaml.MapSlice, rv reflect.Value) {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+
+	for _, k := range keys {
+		e.appendValue(m, k.Interface(), e.transform(rv.MapIndex(k)))
+	}
 }
 
-func fieldName(sf reflect.StructField) string {
-	if s := sf.Tag.Get("mapstructure"); s != "" {
-		return s
+func (e *encoder) appendValue(m *yaml.MapSlice, key, v interface{}) {
+	if v == nil || isEmptyValue(reflect.ValueOf(v)) {
+		return
+	}
+
+	*m = append(*m, yaml.MapItem{Key: key, Value: v})
+}
+
+// isMarshaler reports whether the value encodes itself and must not be transformed.
+func isMarshaler(v interface{}) bool {
+	switch v.(type) {
+	case encoding.TextMarshaler, yaml.BytesMarshaler, yaml.InterfaceMarshaler:
+		return true
+	default:
+		return false
 	}
+}
+
+// A mapstructureTag is a parsed mapstructure struct tag, e.g. `mapstructure:"name,omitempty"`.
+type mapstructureTag struct {
+	name           string
+	squash, remain bool
+}
 
-	if s := sf.Tag.Get("yaml"); s != "" {
+func parseMapstructureTag(sf reflect.StructField) mapstructureTag {
+	opts := strings.Split(sf.Tag.Get("mapstructure"), ",")
+	tag := mapstructureTag{name: opts[0]}
+
+	for _, opt := range opts[1:] {
+		switch opt {
+		case "squash":
+			tag.squash = true
+		case "remain":
+			tag.remain = true
+		}
+	}
+
+	if tag.name == "" {
+		tag.name = fieldName(sf)
+	}
+
+	return tag
+}
+
+func fieldName(sf reflect.StructField) string {
+	if s := sf.Tag.Get("yaml"); s != "" && !strings.HasPrefix(s, ",") {
 		return strings.Split(s, ",")[0]
 	}
 
@@ -158,3 +314,16 @@ var UseMapstructure EncodeOption = func(e *encoder) {
 var UseYAMLV3 EncodeOption = func(e *encoder) {
 	e.useYAMLV3 = true
 }
+
+// Indent sets the number of spaces that nested mappings are indented with, between 2 and 9.
+// The default is 2.
+func Indent(spaces int) EncodeOption {
+	return func(e *encoder) {
+		e.indent = spaces
+	}
+}
+
+// FlushSequences writes the "- " of sequences in mappings at the column of their key instead of indenting them.
+var FlushSequences EncodeOption = func(e *encoder) {
+	e.flushSequences = true
+}
@@ -3,6 +3,7 @@ package yaml_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/faetools/format/yaml"
 	"github.com/golangci/golangci-lint/pkg/config"
@@ -57,3 +58,172 @@ func TestEncode_Mapstructure(t *testing.T) {
   print-linter-name: true
 `, string(res))
 }
+
+func TestEncode_Layout(t *testing.T) {
+	t.Parallel()
+
+	v := map[string]interface{}{"a": map[string]interface{}{"b": []string{"x", "y"}}}
+
+	for _, opts := range [][]yaml.EncodeOption{nil, {yaml.UseYAMLV3}} {
+		res, err := yaml.Encode(v, opts...)
+		require.NoError(t, err)
+		require.Equal(t, "a:\n  b:\n    - x\n    - y\n", string(res))
+
+		res, err = yaml.Encode(v, append(opts, yaml.Indent(4), yaml.FlushSequences)...)
+		require.NoError(t, err)
+		require.Equal(t, "a:\n    b:\n    - x\n    - y\n", string(res))
+	}
+}
+
+func TestEncode_Quoting(t *testing.T) {
+	t.Parallel()
+
+	v := map[string]string{"country": "NO", "enabled": "on", "version": "1.20"}
+
+	res, err := yaml.Encode(v, yaml.Quoting(yaml.YAML11Safe))
+	require.NoError(t, err)
+	require.Equal(t, "country: 'NO'\nenabled: 'on'\nversion: '1.20'\n", string(res))
+
+	res, err = yaml.Encode(v, yaml.PreferDoubleQuotes)
+	require.NoError(t, err)
+	require.Equal(t, "country: NO\nenabled: on\nversion: \"1.20\"\n", string(res))
+}
+
+type (
+	mapstructureBase struct {
+		Name string `mapstructure:"name"`
+	}
+
+	mapstructureItem struct {
+		ID   int    `mapstructure:"id"`
+		Note string `mapstructure:"note,omitempty"`
+	}
+
+	mapstructureConfig struct {
+		Zeta     string                       `mapstructure:"zeta"`
+		Base     *mapstructureBase            `mapstructure:",squash"`
+		Items    []*mapstructureItem          `mapstructure:"items"`
+		ByName   map[string]mapstructureItem  `mapstructure:"by-name"`
+		Skipped  string                       `mapstructure:"-"`
+		Duration time.Duration                `mapstructure:"duration"`
+		Extra    map[string]interface{}       `mapstructure:",remain"`
+		Nested   struct{ Alpha, Beta string } `mapstructure:"nested"`
+	}
+)
+
+func TestEncode_MapstructureNested(t *testing.T) {
+	t.Parallel()
+
+	res, err := yaml.Encode(mapstructureConfig{
+		Zeta:     "last letter",
+		Base:     &mapstructureBase{Name: "base"},
+		Items:    []*mapstructureItem{{ID: 1, Note: "first"}, nil, {ID: 2}},
+		ByName:   map[string]mapstructureItem{"b": {ID: 2}, "a": {ID: 1}},
+		Skipped:  "skipped",
+		Duration: time.Minute,
+		Extra:    map[string]interface{}{"y": 2, "x": []mapstructureItem{{ID: 3}}},
+		Nested:   struct{ Alpha, Beta string }{Beta: "b"},
+	}, yaml.UseMapstructure)
+	require.NoError(t, err)
+
+	require.Equal(t, `zeta: last letter
+name: base
+items:
+  - id: 1
+    note: first
+  - null
+  - id: 2
+by-name:
+  a:
+    id: 1
+  b:
+    id: 2
+duration: 1m0s
+x:
+  - id: 3
+y: 2
+nested:
+  beta: b
+`, string(res))
+}
+
+type (
+	commentedServer struct {
+		Host    string   `yaml:"host" comment:"Host is the address to listen on."`
+		Port    int      `yaml:"port" lineComment:"the default is 8080" default:"8080"`
+		Debug   bool     `yaml:"debug,omitempty" comment:"Debug enables verbose logging."`
+		Plugins []string `yaml:"plugins,omitempty"`
+	}
+
+	commentedConfig struct {
+		Name   string          `yaml:"name"`
+		Server commentedServer `yaml:"server"`
+	}
+)
+
+func (commentedConfig) YAMLComments() map[string]yaml.Comment {
+	return map[string]yaml.Comment{
+		"Name":   {Head: "The name of the service.", Line: "must be unique"},
+		"Server": {Line: "serving settings"},
+	}
+}
+
+// commentedNode is a recursive type.
+type commentedNode struct {
+	Name string         `yaml:"name"`
+	Next *commentedNode `yaml:"next,omitempty" comment:"Next is the following node."`
+}
+
+func TestEncode_Comments(t *testing.T) {
+	t.Parallel()
+
+	v := commentedConfig{Name: "api", Server: commentedServer{Host: "localhost", Port: 80}}
+
+	for _, opts := range [][]yaml.EncodeOption{nil, {yaml.UseYAMLV3}} {
+		res, err := yaml.Encode(v, opts...)
+		require.NoError(t, err)
+		require.Equal(t, `# The name of the service.
+name: api # must be unique
+server: # serving settings
+  # Host is the address to listen on.
+  host: localhost
+  port: 80 # the default is 8080
+`, string(res))
+	}
+
+	res, err := yaml.Encode(commentedConfig{Name: "api"}, yaml.CommentDefaults)
+	require.NoError(t, err)
+	require.Equal(t, `# The name of the service.
+name: api # must be unique
+# server: # serving settings
+#   # Host is the address to listen on.
+#   host: ""
+#   port: 8080 # the default is 8080
+#   # Debug enables verbose logging.
+#   debug: false
+#   plugins: []
+`, string(res))
+
+	res, err = yaml.Encode(commentedConfig{Server: commentedServer{Host: "localhost"}}, yaml.CommentDefaults)
+	require.NoError(t, err)
+	require.Equal(t, `# The name of the service.
+# name: "" # must be unique
+server: # serving settings
+  # Host is the address to listen on.
+  host: localhost
+  # port: 8080 # the default is 8080
+  # Debug enables verbose logging.
+  # debug: false
+  # plugins: []
+`, string(res))
+
+	res, err = yaml.Encode(commentedNode{Name: "a"}, yaml.CommentDefaults)
+	require.NoError(t, err)
+	require.Equal(t, `name: a
+# Next is the following node.
+# next:
+#   name: ""
+#   # Next is the following node.
+#   next: null
+`, string(res))
+}
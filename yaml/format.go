@@ -2,6 +2,7 @@ package yaml
 
 import (
 	"bytes"
+	"io"
 	"strings"
 
 	"github.com/goccy/go-yaml/token"
@@ -15,45 +16,197 @@ const (
 )
 
 // Format formats the yaml file.
-func Format(src []byte) ([]byte, error) {
+// Files may contain several documents, which are separated by "---".
+// Comments at the start of the file, directives like "%YAML 1.2" and the end markers "..." of documents are kept.
+// Blank lines between entries are kept, but never more than one in a row.
+// The layout is set by the options Indent and FlushSequences and keys are ordered by SortKeys and OrderKeys.
+// Strings are quoted as set by Quoting and PreferDoubleQuotes and anchors are changed by ExpandAliases
+// and HoistAnchors. Problems like unused anchors are reported, see WithDiagnostics. All other options are ignored.
+func Format(src []byte, opts ...EncodeOption) ([]byte, error) {
+	e := newEncoder(nil)
+	e.apply(opts)
+
+	return e.format(src)
+}
+
+func (e *encoder) format(src []byte) ([]byte, error) {
+	return e.edit(src, nil)
+}
+
+// edit formats src like format but first calls fn, if set, with every document.
+func (e *encoder) edit(src []byte, fn func(doc *yaml.Node) error) ([]byte, error) {
 	// Skip empty files.
-	if len(src) == 0 {
+	if len(src) == 0 && fn == nil {
 		return src, nil
 	}
 
-	n := &yaml.Node{}
-	if err := yaml.Unmarshal(src, n); err != nil {
-		return nil, errors.Wrap(err, "unmarshalling")
-	}
+	head, docs, stripped := scanDocuments(src)
+
+	dec := yaml.NewDecoder(bytes.NewReader(stripped))
+	lines := strings.Split(string(stripped), "\n")
+	b := &bytes.Buffer{}
+
+	for i := 0; ; i++ {
+		n := &yaml.Node{}
+		if err := dec.Decode(n); errors.Is(err, io.EOF) {
+			// Files with only comments have no documents.
+			if i > 0 {
+				return b.Bytes(), nil
+			} else if fn == nil {
+				return src, nil
+			}
+
+			// Edits of such files add a document after their comments.
+			b.Write(src)
+			if len(src) > 0 && !bytes.HasSuffix(src, []byte("\n")) {
+				b.WriteByte('\n')
+			}
+
+			n.Kind = yaml.DocumentNode
+		} else if err != nil {
+			return nil, errors.Wrap(err, "unmarshalling")
+		}
+
+		if fn != nil {
+			if err := fn(n); err != nil {
+				return nil, err
+			}
+		}
+
+		e.formatAnchors(n)
+		e.formatNode(n, false)
+		e.orderKeys(n, nil)
+		markBlankLines(n, lines)
+
+		body, err := e.encodeDocument(n)
+		if err != nil {
+			return nil, err
+		}
+
+		d := document{}
+		if i < len(docs) {
+			d = docs[i]
+		}
+
+		// The comments at the start of the file belong to the first document but are written before its directives.
+		if i == 0 && d.start && head != "" && strings.HasPrefix(body, head) {
+			b.WriteString(head)
+			body = strings.TrimLeft(body[len(head):], "\n")
+		}
+
+		for _, dir := range d.directives {
+			b.WriteString(dir + "\n")
+		}
+
+		if i > 0 || d.start {
+			b.WriteString("---\n")
+		}
 
-	formatNode(n)
+		b.WriteString(body)
 
+		if d.end {
+			b.WriteString("...\n")
+		}
+	}
+}
+
+// encodeDocument encodes a single document without any document markers.
+func (e *encoder) encodeDocument(n *yaml.Node) (string, error) {
 	b := &bytes.Buffer{}
 	enc := yaml.NewEncoder(b)
-	enc.SetIndent(1)
+	enc.SetIndent(e.indentation())
+
+	if err := enc.Encode(n); err != nil {
+		return "", err
+	}
+
+	out := restoreBlankLines(b.String())
+	if e.flushSequences {
+		out = flushSequences(out)
+	}
+
+	// Empty documents are encoded as empty lines.
+	return strings.TrimLeft(out, "\n"), nil
+}
+
+// A document describes the markers around a document of a yaml file.
+type document struct {
+	directives []string // Directives like "%YAML 1.2".
+	start      bool     // Whether the document starts with "---".
+	end        bool     // Whether the document ends with "...".
+}
 
-	err := enc.Encode(n)
+// scanDocuments returns the comments at the start of the file, the documents of the file in order
+// and the source without directives, which the yaml decoder only supports in part.
+// Directive lines are replaced by empty lines so that errors refer to the right lines.
+func scanDocuments(src []byte) (head string, docs []document, stripped []byte) {
+	lines := strings.Split(string(src), "\n")
 
-	return b.Bytes(), err
+	var (
+		directives []string
+		cur        *document
+		inHead     = true
+	)
+
+	for i, l := range lines {
+		trimmed := strings.TrimSpace(l)
+
+		switch {
+		case strings.HasPrefix(l, "%"):
+			directives = append(directives, strings.TrimRight(l, " \t\r"))
+			lines[i] = ""
+		case isMarker(l, "---"):
+			docs = append(docs, document{directives: directives, start: true})
+			directives, cur = nil, &docs[len(docs)-1]
+		case isMarker(l, "..."):
+			if cur != nil {
+				cur.end = true
+			}
+
+			cur = nil
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			if inHead && trimmed != "" {
+				head += trimmed + "\n"
+			}
+
+			continue
+		case cur == nil:
+			docs = append(docs, document{})
+			cur = &docs[len(docs)-1]
+		}
+
+		inHead = false
+	}
+
+	return head, docs, []byte(strings.Join(lines, "\n"))
+}
+
+// isMarker reports whether the line starts with the document marker.
+func isMarker(line, marker string) bool {
+	return line == marker || strings.HasPrefix(line, marker+" ") ||
+		strings.HasPrefix(line, marker+"\t") || strings.HasPrefix(line, marker+"\r")
 }
 
 func isNeedQuoted(v string) bool {
 	return !strings.ContainsAny(v, "\n\r") && token.IsNeedQuoted(v)
 }
 
-func formatNode(n *yaml.Node) {
+func (e *encoder) formatNode(n *yaml.Node, isKey bool) {
 	switch n.Tag {
 	case tagMerge:
 		n.Tag = "" // Delete this tag.
 	case tagStr:
-		if isNeedQuoted(n.Value) {
-			n.Style = yaml.SingleQuotedStyle
-		} else {
+		switch {
+		case !e.needsQuotes(n.Value, isKey):
 			n.Style = yaml.FlowStyle
+		case e.doubleQuotes:
+			n.Style = yaml.DoubleQuotedStyle
+		default:
+			n.Style = yaml.SingleQuotedStyle
 		}
 	}
 
-	for _, el := range n.Content {
-		formatNode(el)
+	for i, el := range n.Content {
+		e.formatNode(el, n.Kind == yaml.MappingNode && i%2 == 0)
 	}
 }
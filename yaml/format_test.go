@@ -43,9 +43,23 @@ service_name_prefix: production
 <<: *resources`, `# an anchor
 defaults: &resources
   assets_expire_days: '[]'
+
 service_name_prefix: production
 <<: *resources
 `},
+		{"a: 1\n---\nb: \"2\"\n", "a: 1\n---\nb: '2'\n"},
+		{"---\na: 1\n---\nb: 2\n", "---\na: 1\n---\nb: 2\n"},
+		{"# manifests\n---\na: 1\n...\n---\nb: 2\n", "# manifests\n---\na: 1\n...\n---\nb: 2\n"},
+		{"%YAML 1.2\n---\na: 1\n---\nb: 2\n", "%YAML 1.2\n---\na: 1\n---\nb: 2\n"},
+		{"a: 1\n---\n---\nb: 2\n", "a: 1\n---\n---\nb: 2\n"},
+		{"# only a comment\n", "# only a comment\n"},
+		{"a: 1\n\n\n\nb: 2\n", "a: 1\n\nb: 2\n"},
+		{"a: 1\n\n# b\nb: 2\n", "a: 1\n\n# b\nb: 2\n"},
+		{"# header\n\na: 1\n", "# header\n\na: 1\n"},
+		{"a:\n\n  b: 1\n\n  c: 2\n\nd: 3\n\n", "a:\n  b: 1\n\n  c: 2\n\nd: 3\n"},
+		{"a:\n- 1\n\n- 2\n", "a:\n  - 1\n\n  - 2\n"},
+		{"a: |+\n  foo\n\n\nb: 2\n", "a: |+\n  foo\n\n\nb: 2\n"},
+		{"a: [1,\n\n  2]\n", "a: [1, 2]\n"},
 	} {
 		i, tt := i, tt
 		t.Run(fmt.Sprintf("#%d", i), func(t *testing.T) {
@@ -64,4 +78,359 @@ func TestFormat_Error(t *testing.T) {
 
 	_, err := yaml.Format([]byte(`[`))
 	require.EqualError(t, err, "unmarshalling: yaml: line 1: did not find expected node content")
+
+	_, err = yaml.Format([]byte("a: 1\n---\nb: [\n"))
+	require.EqualError(t, err, "unmarshalling: yaml: line 3: did not find expected node content")
+}
+
+func TestFormat_Layout(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`a:
+  b: 1
+  c:
+  - x
+  - y: 1
+    z:
+    - 2
+  # d
+  d: |
+    - not a sequence
+  e:
+  - |
+    text
+  - 3
+f: [1, 2]
+`)
+
+	for i, tt := range []struct {
+		opts []yaml.EncodeOption
+		out  string
+	}{
+		{nil, `a:
+  b: 1
+  c:
+    - x
+    - y: 1
+      z:
+        - 2
+  # d
+  d: |
+    - not a sequence
+  e:
+    - |
+      text
+    - 3
+f: [1, 2]
+`},
+		{[]yaml.EncodeOption{yaml.FlushSequences}, `a:
+  b: 1
+  c:
+  - x
+  - y: 1
+    z:
+    - 2
+  # d
+  d: |
+    - not a sequence
+  e:
+  - |
+    text
+  - 3
+f: [1, 2]
+`},
+		{[]yaml.EncodeOption{yaml.Indent(4)}, `a:
+    b: 1
+    c:
+        - x
+        - y: 1
+          z:
+            - 2
+    # d
+    d: |
+        - not a sequence
+    e:
+        - |
+          text
+        - 3
+f: [1, 2]
+`},
+		{[]yaml.EncodeOption{yaml.Indent(4), yaml.FlushSequences}, `a:
+    b: 1
+    c:
+    - x
+    - y: 1
+      z:
+      - 2
+    # d
+    d: |
+        - not a sequence
+    e:
+    - |
+      text
+    - 3
+f: [1, 2]
+`},
+	} {
+		i, tt := i, tt
+		t.Run(fmt.Sprintf("#%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			res, err := yaml.Format(src, tt.opts...)
+			require.NoError(t, err)
+			require.Equal(t, tt.out, string(res))
+
+			// Formatting is idempotent.
+			res, err = yaml.Format(res, tt.opts...)
+			require.NoError(t, err)
+			require.Equal(t, tt.out, string(res))
+		})
+	}
+}
+
+func TestFormat_KeyOrder(t *testing.T) {
+	t.Parallel()
+
+	for i, tt := range []struct {
+		opts    []yaml.EncodeOption
+		in, out string
+	}{
+		{
+			[]yaml.EncodeOption{yaml.SortKeys},
+			"b: 1\nc:\n  z: 1\n  y: 2\na: [{d: 1, c: 2}]\n",
+			"a: [{c: 2, d: 1}]\nb: 1\nc:\n  y: 2\n  z: 1\n",
+		},
+		{[]yaml.EncodeOption{yaml.OrderKeys(yaml.KubernetesKeyOrder)}, `# a config map
+
+data:
+  foo: bar
+metadata:
+  # the namespace
+  namespace: default
+  name: foo # the name
+kind: ConfigMap
+apiVersion: v1
+`, `# a config map
+
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: foo # the name
+  # the namespace
+  namespace: default
+data:
+  foo: bar
+`},
+		{[]yaml.EncodeOption{yaml.OrderKeys(yaml.GitHubActionsKeyOrder)}, `jobs:
+  test:
+    steps:
+      - run: go test ./...
+        name: Test
+    runs-on: ubuntu-latest
+on: push
+name: CI
+`, `name: CI
+on: push
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - name: Test
+        run: go test ./...
+`},
+		{
+			[]yaml.EncodeOption{yaml.SortKeys, yaml.OrderKeys(yaml.KeyOrder{"$.a[0]": {"z"}, "$.*[*]": {"y"}})},
+			"a:\n  - {x: 1, y: 2, z: 3}\n  - {x: 1, y: 2, z: 3}\n",
+			"a:\n  - {z: 3, y: 2, x: 1}\n  - {y: 2, x: 1, z: 3}\n",
+		},
+	} {
+		i, tt := i, tt
+		t.Run(fmt.Sprintf("#%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			res, err := yaml.Format([]byte(tt.in), tt.opts...)
+			require.NoError(t, err)
+			require.Equal(t, tt.out, string(res))
+		})
+	}
+}
+
+func TestFormat_Quoting(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`country: "NO"
+enabled: 'on'
+answer: 'y'
+duration: '1:20'
+mode: '0777'
+big: '1_000'
+plain: "foo"
+version: "1.20"
+it: "it's"
+`)
+
+	for i, tt := range []struct {
+		opts []yaml.EncodeOption
+		out  string
+	}{
+		{nil, `country: NO
+enabled: on
+answer: y
+duration: '1:20'
+mode: '0777'
+big: '1_000'
+plain: foo
+version: '1.20'
+it: it's
+`},
+		{[]yaml.EncodeOption{yaml.Quoting(yaml.YAML11Safe)}, `country: 'NO'
+enabled: 'on'
+answer: 'y'
+duration: '1:20'
+mode: '0777'
+big: '1_000'
+plain: foo
+version: '1.20'
+it: it's
+`},
+		{[]yaml.EncodeOption{yaml.Quoting(yaml.YAML11Safe), yaml.PreferDoubleQuotes}, `country: "NO"
+enabled: "on"
+answer: "y"
+duration: "1:20"
+mode: "0777"
+big: "1_000"
+plain: foo
+version: "1.20"
+it: it's
+`},
+	} {
+		i, tt := i, tt
+		t.Run(fmt.Sprintf("#%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			res, err := yaml.Format(src, tt.opts...)
+			require.NoError(t, err)
+			require.Equal(t, tt.out, string(res))
+		})
+	}
+}
+
+func TestFormat_QuoteKeys(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("on:\n  push:\ny: 1\nenabled: on\n")
+	yaml11 := yaml.Quoting(yaml.YAML11Safe)
+
+	res, err := yaml.Format(src, yaml.QuoteKeys)
+	require.NoError(t, err)
+	require.Equal(t, "on:\n  push:\ny: 1\nenabled: on\n", string(res))
+
+	res, err = yaml.Format(src, yaml11)
+	require.NoError(t, err)
+	require.Equal(t, "on:\n  push:\ny: 1\nenabled: 'on'\n", string(res))
+
+	res, err = yaml.Format(src, yaml11, yaml.QuoteKeys)
+	require.NoError(t, err)
+	require.Equal(t, "'on':\n  push:\n'y': 1\nenabled: 'on'\n", string(res))
+
+	res, err = yaml.Encode(map[string]string{"on": "yes"}, yaml11, yaml.QuoteKeys)
+	require.NoError(t, err)
+	require.Equal(t, "'on': 'yes'\n", string(res))
+
+	res, err = yaml.Encode(map[string]string{"on": "yes"}, yaml11)
+	require.NoError(t, err)
+	require.Equal(t, "on: 'yes'\n", string(res))
+}
+
+func TestFormat_Anchors(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`defaults: &defaults
+  adapter: postgres
+  host: localhost
+unused: &unused 1
+development:
+  <<: *defaults
+  database: dev
+test:
+  <<: [*defaults, {host: db, port: 5432}]
+  host: test
+hosts:
+  - *defaults
+`)
+
+	var diags []string
+
+	res, err := yaml.Format(src, yaml.ExpandAliases, yaml.WithDiagnostics(func(d yaml.Diagnostic) {
+		diags = append(diags, d.String())
+	}))
+	require.NoError(t, err)
+	require.Equal(t, `defaults:
+  adapter: postgres
+  host: localhost
+unused: 1
+development:
+  adapter: postgres
+  host: localhost
+  database: dev
+test:
+  adapter: postgres
+  port: 5432
+  host: test
+hosts:
+  - adapter: postgres
+    host: localhost
+`, string(res))
+	require.Equal(t, []string{`4:9: anchor "unused" is never used`}, diags)
+
+	res, err = yaml.Format([]byte(`prod:
+  resources:
+    cpu: 1
+    memory: 1Gi
+  replicas: 2
+staging:
+  resources:
+    cpu: 1
+    memory: 1Gi
+  replicas: 1
+dev:
+  # smaller
+  resources:
+    cpu: 1
+    memory: 1Gi
+`), yaml.HoistAnchors)
+	require.NoError(t, err)
+	require.Equal(t, `prod:
+  resources: &resources
+    cpu: 1
+    memory: 1Gi
+  replicas: 2
+staging:
+  resources: *resources
+  replicas: 1
+dev:
+  # smaller
+  resources: *resources
+`, string(res))
+
+	// Mappings containing anchors are kept.
+	res, err = yaml.Format([]byte(`prod:
+  cpu: 1
+  memory: 1Gi
+staging:
+  cpu: 1
+  memory: &memory 1Gi
+dev:
+  memory: *memory
+`), yaml.HoistAnchors)
+	require.NoError(t, err)
+	require.Equal(t, `prod:
+  cpu: 1
+  memory: 1Gi
+staging:
+  cpu: 1
+  memory: &memory 1Gi
+dev:
+  memory: *memory
+`, string(res))
 }
@@ -0,0 +1,240 @@
+package yaml
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A KeyOrder lists the keys of mappings in the order they should be in, by the path of the mappings.
+// Paths start with "$" for the document, followed by ".key" for the value of a key, ".*" for the values of all keys,
+// "[0]" for an entry of a sequence and "[*]" for all entries, e.g. "$.jobs.*.steps[*]".
+// The listed keys are put first, all other keys follow in their original order.
+type KeyOrder map[string][]string
+
+// Key orders of well-known files.
+var (
+	// KubernetesKeyOrder orders Kubernetes manifests.
+	KubernetesKeyOrder = KeyOrder{
+		"$":                                  {"apiVersion", "kind", "metadata", "type", "spec", "data", "stringData", "status"},
+		"$.metadata":                         kubernetesMetadata,
+		"$.spec.template.metadata":           kubernetesMetadata,
+		"$.spec.containers[*]":               kubernetesContainer,
+		"$.spec.template.spec.containers[*]": kubernetesContainer,
+	}
+
+	// GitHubActionsKeyOrder orders GitHub Actions workflows.
+	GitHubActionsKeyOrder = KeyOrder{
+		"$": {"name", "run-name", "on", "permissions", "env", "defaults", "concurrency", "jobs"},
+		"$.jobs.*": {
+			"name", "needs", "if", "runs-on", "environment", "permissions", "concurrency", "outputs", "env", "defaults",
+			"timeout-minutes", "strategy", "continue-on-error", "container", "services", "steps",
+		},
+		"$.jobs.*.steps[*]": {
+			"name", "id", "if", "uses", "with", "run", "shell", "working-directory", "env",
+			"continue-on-error", "timeout-minutes",
+		},
+	}
+
+	// DockerComposeKeyOrder orders docker-compose files.
+	DockerComposeKeyOrder = KeyOrder{
+		"$": {"version", "name", "services", "networks", "volumes", "configs", "secrets"},
+		"$.services.*": {
+			"image", "build", "container_name", "command", "entrypoint", "environment", "env_file",
+			"ports", "expose", "volumes", "depends_on", "networks", "restart", "healthcheck",
+		},
+	}
+
+	// InfoKeyOrder orders info.yaml files that describe repositories.
+	InfoKeyOrder = KeyOrder{
+		"$": {"name", "version", "repoType", "library", "team", "devToolVersion"},
+	}
+
+	kubernetesMetadata  = []string{"name", "namespace", "labels", "annotations"}
+	kubernetesContainer = []string{
+		"name", "image", "imagePullPolicy", "command", "args", "workingDir", "env", "envFrom", "ports",
+		"volumeMounts", "resources",
+	}
+)
+
+// KeyOrderProfiles are the key orders of well-known files by name.
+var KeyOrderProfiles = map[string]KeyOrder{
+	"kubernetes":    KubernetesKeyOrder,
+	"githubActions": GitHubActionsKeyOrder,
+	"dockerCompose": DockerComposeKeyOrder,
+	"info":          InfoKeyOrder,
+}
+
+// SortKeys sorts the keys of all mappings alphabetically, after the keys that are ordered by OrderKeys.
+var SortKeys EncodeOption = func(e *encoder) {
+	e.sortKeys = true
+}
+
+// OrderKeys orders the keys of mappings when formatting, see KeyOrder.
+// If several paths match a mapping, the keys of more specific paths come first.
+// Comments of the keys are moved with them.
+func OrderKeys(order KeyOrder) EncodeOption {
+	return func(e *encoder) {
+		for path, keys := range order {
+			e.keyOrders = append(e.keyOrders, keyOrder{path: splitPath(path), keys: keys})
+		}
+
+		// Paths with fewer wildcards are more specific.
+		sort.SliceStable(e.keyOrders, func(i, j int) bool {
+			return wildcards(e.keyOrders[i].path) < wildcards(e.keyOrders[j].path)
+		})
+	}
+}
+
+// keyOrder is a parsed KeyOrder entry.
+type keyOrder struct {
+	path []string
+	keys []string
+}
+
+// splitPath splits a path like "$.jobs.*.steps[*]" into its elements "jobs", "*", "steps" and "[*]".
+func splitPath(path string) []string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return nil
+	}
+
+	var elems []string
+
+	for _, key := range strings.Split(path, ".") {
+		for {
+			i := strings.IndexByte(key, '[')
+			if i < 0 {
+				break
+			}
+
+			if i > 0 {
+				elems = append(elems, key[:i])
+			}
+
+			end := strings.IndexByte(key[i:], ']')
+			if end < 0 {
+				break
+			}
+
+			elems = append(elems, key[i:i+end+1])
+			key = key[i+end+1:]
+		}
+
+		if key != "" {
+			elems = append(elems, key)
+		}
+	}
+
+	return elems
+}
+
+func wildcards(path []string) (n int) {
+	for _, elem := range path {
+		if elem == "*" || elem == "[*]" {
+			n++
+		}
+	}
+
+	return n
+}
+
+// matchPath reports whether the path of a node matches the path of a key order.
+func matchPath(pattern, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+
+	for i, p := range pattern {
+		isIndex := strings.HasPrefix(path[i], "[")
+
+		switch {
+		case p == "*" && !isIndex, p == "[*]" && isIndex, p == path[i]:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// orderedKeys returns the keys of all key orders that match the path, the most specific first.
+func (e *encoder) orderedKeys(path []string) []string {
+	var keys []string
+
+	for _, o := range e.keyOrders {
+		if matchPath(o.path, path) {
+			keys = append(keys, o.keys...)
+		}
+	}
+
+	return keys
+}
+
+// orderKeys orders the keys of all mappings in the node.
+func (e *encoder) orderKeys(n *yaml.Node, path []string) {
+	if !e.sortKeys && len(e.keyOrders) == 0 {
+		return
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, el := range n.Content {
+			e.orderKeys(el, path)
+		}
+	case yaml.SequenceNode:
+		for i, el := range n.Content {
+			e.orderKeys(el, append(path[:len(path):len(path)], "["+strconv.Itoa(i)+"]"))
+		}
+	case yaml.MappingNode:
+		e.orderMapping(n, e.orderedKeys(path))
+
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			e.orderKeys(n.Content[i+1], append(path[:len(path):len(path)], n.Content[i].Value))
+		}
+	}
+}
+
+// orderMapping puts the listed keys of the mapping first and sorts the rest if configured.
+func (e *encoder) orderMapping(n *yaml.Node, keys []string) {
+	if !e.sortKeys && len(keys) == 0 {
+		return
+	}
+
+	rank := map[string]int{}
+
+	for i, k := range keys {
+		if _, ok := rank[k]; !ok {
+			rank[k] = i
+		}
+	}
+
+	rankOf := func(k string) int {
+		if r, ok := rank[k]; ok {
+			return r
+		}
+
+		return len(keys)
+	}
+
+	// Keys and values are moved in pairs, together with their comments.
+	pairs := make([][2]*yaml.Node, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		a, b := pairs[i][0].Value, pairs[j][0].Value
+		if ra, rb := rankOf(a), rankOf(b); ra != rb {
+			return ra < rb
+		}
+
+		return e.sortKeys && rankOf(a) == len(keys) && a < b
+	})
+
+	for i, p := range pairs {
+		n.Content[2*i], n.Content[2*i+1] = p[0], p[1]
+	}
+}
@@ -0,0 +1,365 @@
+package yaml
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// A Match is a node found by Query.
+type Match struct {
+	// Document is the index of the document containing the node.
+	Document int
+	// Path is the path of the node, e.g. $.jobs.build.steps[1].
+	Path string
+	// Key is the key of the node if it is the value of a mapping.
+	Key *yaml.Node
+	// Node is the node itself, including its position.
+	Node *yaml.Node
+}
+
+// Source returns the lines of src the match was found in.
+func (m Match) Source(src []byte) string {
+	start, end := m.Node.Line, lastLine(m.Node)
+	if m.Key != nil && m.Key.Line < start {
+		start = m.Key.Line
+	}
+
+	lines := strings.Split(string(src), "\n")
+	if start < 1 || end > len(lines) {
+		return ""
+	}
+
+	return strings.Join(lines[start-1:end], "\n")
+}
+
+// Query returns all nodes of all documents in src that match path.
+//
+// Path is a YAMLPath expression like $.jobs.*.steps[?(@.uses)]. It supports keys (.key or ['key']), indices ([0],
+// [-1]), wildcards (.* and [*]), recursive descent (..key) and filters on the existence ([?(@.key)]) or the value
+// ([?(@.key == 'value')] or !=) of a key.
+func Query(src []byte, path string) ([]Match, error) {
+	sels, err := parseQuery(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, _, stripped := scanDocuments(src)
+	dec := yaml.NewDecoder(bytes.NewReader(stripped))
+
+	var matches []Match
+
+	for i := 0; ; i++ {
+		doc := &yaml.Node{}
+		if err := dec.Decode(doc); errors.Is(err, io.EOF) {
+			return matches, nil
+		} else if err != nil {
+			return nil, errors.Wrap(err, "unmarshalling")
+		}
+
+		if len(doc.Content) == 0 {
+			continue
+		}
+
+		matches = append(matches, selectAll([]Match{{Document: i, Path: "$", Node: doc.Content[0]}}, sels)...)
+	}
+}
+
+type selectorKind int
+
+const (
+	selectKey selectorKind = iota
+	selectAnyKey
+	selectIndex
+	selectAnyIndex
+	selectDescendants
+	selectFilter
+)
+
+// A selector is a single step of a path.
+type selector struct {
+	kind   selectorKind
+	key    string
+	index  int
+	filter *filter
+}
+
+// A filter keeps the children that have the key at path, optionally with a certain value.
+type filter struct {
+	path  []selector
+	op    string
+	value string
+}
+
+// parseQuery parses a YAMLPath expression.
+func parseQuery(path string) ([]selector, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, errors.Errorf("invalid path %q: must start with $", path)
+	}
+
+	sels, rest, err := parseSelectors(path[1:])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid path %q", path)
+	}
+
+	if rest != "" {
+		return nil, errors.Errorf("invalid path %q: unexpected %q", path, rest)
+	}
+
+	return sels, nil
+}
+
+// parseSelectors parses selectors until the end of s or a character that cannot continue a path.
+func parseSelectors(s string) (sels []selector, rest string, err error) {
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			sels = append(sels, selector{kind: selectDescendants})
+			s = s[1:]
+		case s[0] == '.':
+			n := strings.IndexAny(s[1:], ".[ )=!")
+			if n < 0 {
+				n = len(s) - 1
+			}
+
+			switch key := s[1 : n+1]; key {
+			case "":
+				return nil, "", errors.New("empty key")
+			case "*":
+				sels = append(sels, selector{kind: selectAnyKey})
+			default:
+				sels = append(sels, selector{kind: selectKey, key: key})
+			}
+
+			s = s[n+1:]
+		case s[0] == '[':
+			sel, n, err := parseBracket(s)
+			if err != nil {
+				return nil, "", err
+			}
+
+			sels = append(sels, sel)
+			s = s[n:]
+		default:
+			return sels, s, nil
+		}
+	}
+
+	return sels, "", nil
+}
+
+// parseBracket parses the selector in brackets at the start of s and returns it with its length.
+func parseBracket(s string) (selector, int, error) {
+	if strings.HasPrefix(s, "[?(") {
+		f, n, err := parseFilter(s[3:])
+		if err != nil {
+			return selector{}, 0, err
+		}
+
+		if !strings.HasPrefix(s[3+n:], ")]") {
+			return selector{}, 0, errors.Errorf("unterminated filter %q", s)
+		}
+
+		return selector{kind: selectFilter, filter: f}, 3 + n + 2, nil
+	}
+
+	if strings.HasPrefix(s, "['") {
+		end := strings.Index(s[2:], "']")
+		if end < 0 {
+			return selector{}, 0, errors.Errorf("unterminated key %q", s)
+		}
+
+		return selector{kind: selectKey, key: s[2 : 2+end]}, 2 + end + 2, nil
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return selector{}, 0, errors.Errorf("unterminated index %q", s)
+	}
+
+	if s[1:end] == "*" {
+		return selector{kind: selectAnyIndex}, end + 1, nil
+	}
+
+	i, err := strconv.Atoi(s[1:end])
+	if err != nil {
+		return selector{}, 0, errors.Errorf("invalid index %q", s[:end+1])
+	}
+
+	return selector{kind: selectIndex, index: i}, end + 1, nil
+}
+
+// parseFilter parses a filter expression like @.key == 'value' at the start of s and returns it with its length.
+func parseFilter(s string) (*filter, int, error) {
+	if !strings.HasPrefix(s, "@") {
+		return nil, 0, errors.Errorf("filter %q must start with @", s)
+	}
+
+	sels, rest, err := parseSelectors(s[1:])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f := &filter{path: sels}
+
+	trimmed := strings.TrimLeft(rest, " ")
+	for _, op := range []string{"==", "!="} {
+		if strings.HasPrefix(trimmed, op) {
+			f.op = op
+		}
+	}
+
+	if f.op != "" {
+		value := trimmed[len(f.op):]
+
+		end := strings.Index(value, ")]")
+		if end < 0 {
+			end = len(value)
+		}
+
+		f.value = strings.TrimSpace(value[:end])
+		if l := len(f.value); l >= 2 && strings.ContainsAny(f.value[:1], `'"`) && f.value[l-1] == f.value[0] {
+			f.value = f.value[1 : l-1]
+		}
+
+		rest = value[end:]
+	}
+
+	return f, len(s) - len(rest), nil
+}
+
+// selectAll applies sels to the matches.
+func selectAll(matches []Match, sels []selector) []Match {
+	for _, sel := range sels {
+		var next []Match
+
+		for _, m := range matches {
+			next = append(next, sel.apply(m)...)
+		}
+
+		matches = next
+	}
+
+	return matches
+}
+
+func (sel selector) apply(m Match) []Match {
+	n := m.Node
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	switch sel.kind {
+	case selectKey:
+		if n.Kind == yaml.MappingNode {
+			if i := keyIndex(n, sel.key); i >= 0 {
+				return []Match{m.child(n.Content[i], n.Content[i+1])}
+			}
+		}
+	case selectIndex:
+		if n.Kind == yaml.SequenceNode {
+			if i, ok := sequenceIndex(n, "["+strconv.Itoa(sel.index)+"]"); ok {
+				return []Match{m.item(i, n.Content[i])}
+			}
+		}
+	case selectAnyKey, selectAnyIndex:
+		return children(m, n, sel.kind)
+	case selectDescendants:
+		res := []Match{m}
+		for _, c := range children(m, n, selectAnyKey) {
+			res = append(res, sel.apply(c)...)
+		}
+
+		return res
+	case selectFilter:
+		var res []Match
+
+		for _, c := range children(m, n, selectAnyKey) {
+			if sel.filter.matches(c) {
+				res = append(res, c)
+			}
+		}
+
+		return res
+	}
+
+	return nil
+}
+
+// children returns the values of a mapping or the entries of a sequence; [*] only selects the latter.
+func children(m Match, n *yaml.Node, kind selectorKind) []Match {
+	var res []Match
+
+	switch {
+	case n.Kind == yaml.MappingNode && kind != selectAnyIndex:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			res = append(res, m.child(n.Content[i], n.Content[i+1]))
+		}
+	case n.Kind == yaml.SequenceNode:
+		for i, el := range n.Content {
+			res = append(res, m.item(i, el))
+		}
+	}
+
+	return res
+}
+
+func (f *filter) matches(m Match) bool {
+	found := selectAll([]Match{m}, f.path)
+
+	switch f.op {
+	case "==":
+		for _, c := range found {
+			if c.Node.Kind == yaml.ScalarNode && c.Node.Value == f.value {
+				return true
+			}
+		}
+
+		return false
+	case "!=":
+		for _, c := range found {
+			if c.Node.Kind == yaml.ScalarNode && c.Node.Value == f.value {
+				return false
+			}
+		}
+
+		return len(found) > 0
+	default:
+		return len(found) > 0
+	}
+}
+
+// child returns the match of the value of key.
+func (m Match) child(key, value *yaml.Node) Match {
+	p := m.Path + "." + key.Value
+	if strings.ContainsAny(key.Value, ".[]' ") {
+		p = m.Path + "['" + key.Value + "']"
+	}
+
+	return Match{Document: m.Document, Path: p, Key: key, Node: value}
+}
+
+// item returns the match of the i-th sequence entry.
+func (m Match) item(i int, n *yaml.Node) Match {
+	return Match{Document: m.Document, Path: m.Path + "[" + strconv.Itoa(i) + "]", Node: n}
+}
+
+// lastLine returns the last line n spans in its source.
+func lastLine(n *yaml.Node) int {
+	last := n.Line
+	if n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		last += strings.Count(strings.TrimSuffix(n.Value, "\n"), "\n") + 1
+	}
+
+	for _, c := range n.Content {
+		if l := lastLine(c); l > last {
+			last = l
+		}
+	}
+
+	return last
+}
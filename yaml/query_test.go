@@ -0,0 +1,117 @@
+package yaml_test
+
+import (
+	"testing"
+
+	"github.com/faetools/format/yaml"
+	"github.com/stretchr/testify/require"
+)
+
+const workflow = `name: CI
+jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v3
+      - run: make
+  test:
+    steps:
+      - name: test
+        uses: actions/setup-go@v4
+        with:
+          go-version: "1.18"
+      - run: |
+          go test ./...
+          go vet ./...
+`
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		path  string
+		paths []string
+	}{
+		{"$", []string{"$"}},
+		{"$.name", []string{"$.name"}},
+		{"$.jobs.*", []string{"$.jobs.build", "$.jobs.test"}},
+		{"$.jobs.*.steps[?(@.uses)]", []string{"$.jobs.build.steps[0]", "$.jobs.test.steps[0]"}},
+		{"$.jobs.*.steps[?(@.uses)].uses", []string{"$.jobs.build.steps[0].uses", "$.jobs.test.steps[0].uses"}},
+		{"$.jobs.*.steps[?(@.name == 'test')].uses", []string{"$.jobs.test.steps[0].uses"}},
+		{`$.jobs.*.steps[?(@.uses != "actions/checkout@v3")]`, []string{"$.jobs.test.steps[0]"}},
+		{"$.jobs.*.steps[?(@.with.go-version)]", []string{"$.jobs.test.steps[0]"}},
+		{"$.jobs.build.steps[-1].run", []string{"$.jobs.build.steps[1].run"}},
+		{"$.jobs.*.steps[*].run", []string{"$.jobs.build.steps[1].run", "$.jobs.test.steps[1].run"}},
+		{"$..uses", []string{"$.jobs.build.steps[0].uses", "$.jobs.test.steps[0].uses"}},
+		{"$.jobs['build'].steps[0]", []string{"$.jobs.build.steps[0]"}},
+		{"$.missing", nil},
+		{"$.jobs[0]", nil},
+	} {
+		tt := tt
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			matches, err := yaml.Query([]byte(workflow), tt.path)
+			require.NoError(t, err)
+
+			var paths []string
+			for _, m := range matches {
+				paths = append(paths, m.Path)
+			}
+
+			require.Equal(t, tt.paths, paths)
+		})
+	}
+}
+
+func TestQuery_Positions(t *testing.T) {
+	t.Parallel()
+
+	matches, err := yaml.Query([]byte(workflow), "$.jobs.test.steps[?(@.uses)]")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	m := matches[0]
+	require.Equal(t, 9, m.Node.Line)
+	require.Equal(t, 9, m.Node.Column)
+	require.Nil(t, m.Key)
+	require.Equal(t, `      - name: test
+        uses: actions/setup-go@v4
+        with:
+          go-version: "1.18"`, m.Source([]byte(workflow)))
+
+	matches, err = yaml.Query([]byte(workflow), "$.jobs.test.steps[1].run")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.Equal(t, 13, matches[0].Key.Line)
+	require.Equal(t, "      - run: |\n          go test ./...\n          go vet ./...", matches[0].Source([]byte(workflow)))
+}
+
+func TestQuery_Documents(t *testing.T) {
+	t.Parallel()
+
+	matches, err := yaml.Query([]byte("kind: Service\n---\nkind: Deployment\n"), "$.kind")
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	require.Equal(t, 1, matches[1].Document)
+	require.Equal(t, "Deployment", matches[1].Node.Value)
+	require.Equal(t, 3, matches[1].Node.Line)
+}
+
+func TestQuery_Error(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct{ path, err string }{
+		{"jobs", `invalid path "jobs": must start with $`},
+		{"$.jobs[", `invalid path "$.jobs[": unterminated index "["`},
+		{"$.jobs[a]", `invalid path "$.jobs[a]": invalid index "[a]"`},
+		{"$.jobs[?(@.uses]", `invalid path "$.jobs[?(@.uses]": unterminated filter "[?(@.uses]"`},
+		{"$.jobs[?(uses)]", `invalid path "$.jobs[?(uses)]": filter "uses)]" must start with @`},
+		{"$.jobs x", `invalid path "$.jobs x": unexpected " x"`},
+	} {
+		_, err := yaml.Query([]byte(workflow), tt.path)
+		require.EqualError(t, err, tt.err, tt.path)
+	}
+
+	_, err := yaml.Query([]byte("a: [\n"), "$.a")
+	require.EqualError(t, err, "unmarshalling: yaml: line 1: did not find expected node content")
+}
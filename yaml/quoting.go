@@ -0,0 +1,112 @@
+package yaml
+
+import (
+	"regexp"
+
+	goyaml "github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/token"
+)
+
+// A QuotingPolicy decides which strings are quoted.
+type QuotingPolicy int
+
+const (
+	// YAML12 only quotes strings that aren't strings in YAML 1.2 or can't be written without quotes.
+	YAML12 QuotingPolicy = iota
+	// YAML11Safe quotes all values that aren't strings in either YAML 1.1 or YAML 1.2,
+	// e.g. yes, on, y or 1:20, so that readers of YAML 1.1 like PyYAML or yaml.v2 don't change their type.
+	// Keys are only quoted like this with QuoteKeys.
+	YAML11Safe
+)
+
+// Quoting sets the policy which strings are quoted, YAML12 by default.
+func Quoting(p QuotingPolicy) EncodeOption {
+	return func(e *encoder) {
+		e.quoting = p
+	}
+}
+
+// QuoteKeys also quotes mapping keys that aren't strings in YAML 1.1, e.g. on in GitHub Actions workflows,
+// if the quoting policy is YAML11Safe.
+var QuoteKeys EncodeOption = func(e *encoder) {
+	e.quoteKeys = true
+}
+
+// PreferDoubleQuotes quotes strings with double quotes instead of single quotes.
+var PreferDoubleQuotes EncodeOption = func(e *encoder) {
+	e.doubleQuotes = true
+}
+
+// The plain scalars that aren't strings in YAML 1.1.
+var (
+	rxYAML11Bool = regexp.MustCompile(`^(?:y|Y|yes|Yes|YES|n|N|no|No|NO|true|True|TRUE|false|False|FALSE|` +
+		`on|On|ON|off|Off|OFF)$`)
+	rxYAML11Int = regexp.MustCompile(`^(?:[-+]?0b[0-1_]+|[-+]?0[0-7_]+|[-+]?(?:0|[1-9][0-9_]*)|` +
+		`[-+]?0x[0-9a-fA-F_]+|[-+]?[1-9][0-9_]*(?::[0-5]?[0-9])+)$`)
+	rxYAML11Float = regexp.MustCompile(`^(?:[-+]?[0-9][0-9_]*\.[0-9_]*(?:[eE][-+][0-9]+)?|` +
+		`\.[0-9][0-9_]*(?:[eE][-+][0-9]+)?|[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])+\.[0-9_]*|` +
+		`[-+]?\.(?:inf|Inf|INF)|\.(?:nan|NaN|NAN))$`)
+	rxYAML11Null      = regexp.MustCompile(`^(?:~|null|Null|NULL)$`)
+	rxYAML11Timestamp = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}` +
+		`(?:(?:[Tt]|[ \t]+)[0-9]{1,2}:[0-9]{2}:[0-9]{2}(?:\.[0-9]*)?(?:[ \t]*(?:Z|[-+][0-9]{1,2}(?::[0-9]{2})?))?)?$`)
+)
+
+// isYAML11NonString reports whether the plain scalar is not a string in YAML 1.1.
+func isYAML11NonString(v string) bool {
+	for _, rx := range []*regexp.Regexp{rxYAML11Bool, rxYAML11Int, rxYAML11Float, rxYAML11Null, rxYAML11Timestamp} {
+		if rx.MatchString(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// needsQuotes reports whether the string, a key if isKey is set, needs to be quoted according to the quoting policy.
+func (e *encoder) needsQuotes(v string, isKey bool) bool {
+	return isNeedQuoted(v) || e.quoting == YAML11Safe && (!isKey || e.quoteKeys) && isYAML11NonString(v)
+}
+
+// quoteYAML11 quotes all plain strings of the node that aren't strings in YAML 1.1.
+// They are already quoted if they aren't strings in YAML 1.2.
+func (e *encoder) quoteYAML11(n ast.Node) {
+	if e.quoting != YAML11Safe {
+		return
+	}
+
+	ast.Walk(quoteVisitor{e}, n)
+}
+
+type quoteVisitor struct{ e *encoder }
+
+func (v quoteVisitor) Visit(n ast.Node) ast.Visitor {
+	if mv, ok := n.(*ast.MappingValueNode); ok && !v.e.quoteKeys {
+		// Skip the key.
+		ast.Walk(v, mv.Value)
+
+		return nil
+	}
+
+	s, ok := n.(*ast.StringNode)
+	if !ok || s.Token.Type != token.StringType || !isYAML11NonString(s.Value) {
+		return v
+	}
+
+	s.Token.Type = token.SingleQuoteType
+	if v.e.doubleQuotes {
+		s.Token.Type = token.DoubleQuoteType
+	}
+
+	return v
+}
+
+// encodeOptions returns the options of github.com/goccy/go-yaml that correspond to the options of the encoder.
+func (e *encoder) encodeOptions() []goyaml.EncodeOption {
+	return []goyaml.EncodeOption{
+		goyaml.UseLiteralStyleIfMultiline(true),
+		goyaml.Indent(e.indentation()),
+		goyaml.IndentSequence(!e.flushSequences),
+		goyaml.UseSingleQuote(!e.doubleQuotes),
+	}
+}
@@ -0,0 +1,113 @@
+package yaml
+
+import "strings"
+
+// A sequence is a block sequence that is the value of a mapping key.
+type sequence struct {
+	column int // The column of the "- " of its entries.
+	shift  int // The number of columns that its lines are moved to the left.
+}
+
+// flushSequences moves block sequences that are values of mapping keys to the column of their key.
+// The yaml encoder always indents them.
+func flushSequences(out string) string {
+	lines := strings.Split(out, "\n")
+
+	var (
+		seqs   []sequence
+		scalar = -1 // The column that the contents of the current block scalar are indented beyond.
+	)
+
+	shift := func() int {
+		if len(seqs) == 0 {
+			return 0
+		}
+
+		return seqs[len(seqs)-1].shift
+	}
+
+	for i, l := range lines {
+		trimmed := strings.TrimLeft(l, " ")
+		if trimmed == "" {
+			continue
+		}
+
+		indent := len(l) - len(trimmed)
+
+		if scalar >= 0 {
+			if indent > scalar {
+				lines[i] = l[shift():]
+				continue
+			}
+
+			scalar = -1
+		}
+
+		for len(seqs) > 0 && indent < seqs[len(seqs)-1].column {
+			seqs = seqs[:len(seqs)-1]
+		}
+
+		lines[i] = l[shift():]
+
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		content := contentColumn(l)
+		value := strings.TrimRight(l[content:], " ")
+
+		switch {
+		case isBlockScalarHeader(value):
+			// The contents of a block scalar entry of a sequence are indented beyond its "- ".
+			scalar = content
+			if !strings.Contains(value, ": ") {
+				scalar -= 2
+			}
+		case strings.HasSuffix(value, ":"):
+			if col, ok := nextSequence(lines[i+1:]); ok && col > content {
+				seqs = append(seqs, sequence{column: col, shift: shift() + col - content})
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// contentColumn returns the column after any "- " at the start of the line.
+func contentColumn(l string) int {
+	col := len(l) - len(strings.TrimLeft(l, " "))
+
+	for strings.HasPrefix(l[col:], "- ") {
+		col += 2
+	}
+
+	return col
+}
+
+// isBlockScalarHeader reports whether the value ends with the header of a literal or folded block scalar, e.g. "|-".
+func isBlockScalarHeader(value string) bool {
+	i := strings.LastIndexAny(value, "|>")
+	if i < 0 || i > 0 && value[i-1] != ' ' || strings.Trim(value[i+1:], "+-0123456789") != "" {
+		return false
+	}
+
+	return i == 0 || strings.HasSuffix(value[:i], ": ") || strings.HasSuffix(value[:i], "- ")
+}
+
+// nextSequence returns the column of the first entry of the sequence if the lines start with one,
+// skipping blank lines and comments.
+func nextSequence(lines []string) (int, bool) {
+	for _, l := range lines {
+		trimmed := strings.TrimLeft(l, " ")
+
+		switch {
+		case trimmed == "", strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(trimmed, "- ") || trimmed == "-":
+			return len(l) - len(trimmed), true
+		default:
+			return 0, false
+		}
+	}
+
+	return 0, false
+}